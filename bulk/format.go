@@ -0,0 +1,378 @@
+// Package bulk moves key/value pairs between files and a kvserver.
+//
+// Files come in four formats:
+//
+//	jsonl     one {"key": ..., "value": ...} object per line
+//	csv       a key,value header then one quoted record per pair
+//	snapshot  the data.json format of the server: a unix time line, then a
+//	          JSON array of {"Key", "Value", "Version", "Deleted"} objects
+//	log       the history.log format of the server, also used by the
+//	          benchmark datasets: version "key" "value" done per line, see
+//	          FormatLogRecord
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats lists the supported file formats.
+var Formats = []string{"jsonl", "csv", "snapshot", "log"}
+
+// maxLine bounds a line of the jsonl and log formats
+const maxLine = 1024 * 1024 * 16
+
+// Record is a key with its value, or a deleted key. Version is only used by
+// the snapshot and log writers, which stamp the time of the write if it is 0.
+// Replicas is the number of servers the server keeps the key on, 0 for all of
+// them, and only kept by the log format.
+type Record struct {
+	Key      string
+	Value    string
+	Deleted  bool
+	Version  int64
+	Replicas uint32
+}
+
+// Reader reads records until it returns io.EOF.
+type Reader interface {
+	Read() (Record, error)
+}
+
+// Writer writes records, Close completes the file without closing the
+// underlying writer.
+type Writer interface {
+	Write(r Record) error
+	Close() error
+}
+
+// FormatOf guesses the format of a file from its name, jsonl if unsure.
+func FormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return "csv"
+	case ".log", ".txt":
+		return "log"
+	}
+	if strings.HasPrefix(filepath.Base(filename), "data.json") {
+		return "snapshot"
+	}
+	return "jsonl"
+}
+
+// NewReader reads records of the given format from r.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case "jsonl":
+		return &jsonlReader{lines: newLineScanner(r)}, nil
+	case "csv":
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = 2
+		return &csvReader{r: cr}, nil
+	case "snapshot":
+		return &snapshotReader{r: bufio.NewReader(r)}, nil
+	case "log":
+		return &logReader{lines: newLineScanner(r)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// NewWriter writes records of the given format to w.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case "jsonl":
+		return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case "csv":
+		cw := csv.NewWriter(bw)
+		return &csvWriter{w: bw, cw: cw}, cw.Write([]string{"key", "value"})
+	case "snapshot":
+		return &snapshotWriter{w: bw}, nil
+	case "log":
+		return &logWriter{w: bw}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type lineScanner struct {
+	*bufio.Scanner
+	line int
+}
+
+func newLineScanner(r io.Reader) *lineScanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), maxLine)
+	return &lineScanner{Scanner: scanner}
+}
+
+// next returns the next line that is not blank
+func (s *lineScanner) next() (string, error) {
+	for s.Scan() {
+		s.line++
+		if strings.TrimSpace(s.Text()) != "" {
+			return s.Text(), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+type jsonRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type jsonlReader struct {
+	lines *lineScanner
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	line, err := r.lines.next()
+	if err != nil {
+		return Record{}, err
+	}
+	var j jsonRecord
+	if err := json.Unmarshal([]byte(line), &j); err != nil {
+		return Record{}, fmt.Errorf("line %d: %v", r.lines.line, err)
+	}
+	return Record{Key: j.Key, Value: j.Value}, nil
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(r Record) error {
+	if r.Deleted {
+		return nil
+	}
+	return w.enc.Encode(jsonRecord{Key: r.Key, Value: r.Value})
+}
+
+func (w *jsonlWriter) Close() error {
+	return w.w.Flush()
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header bool
+}
+
+func (r *csvReader) Read() (Record, error) {
+	fields, err := r.r.Read()
+	if err != nil {
+		return Record{}, err
+	}
+	if !r.header {
+		r.header = true
+		if fields[0] == "key" && fields[1] == "value" {
+			return r.Read()
+		}
+	}
+	return Record{Key: fields[0], Value: fields[1]}, nil
+}
+
+type csvWriter struct {
+	w  *bufio.Writer
+	cw *csv.Writer
+}
+
+func (w *csvWriter) Write(r Record) error {
+	if r.Deleted {
+		return nil
+	}
+	return w.cw.Write([]string{r.Key, r.Value})
+}
+
+func (w *csvWriter) Close() error {
+	w.cw.Flush()
+	if err := w.cw.Error(); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// snapshotEntry matches the JsonData of the server
+type snapshotEntry struct {
+	Key     string
+	Value   string
+	Version int64
+	Deleted bool
+}
+
+type snapshotReader struct {
+	r       *bufio.Reader
+	decoder *json.Decoder
+}
+
+func (r *snapshotReader) Read() (Record, error) {
+	if r.decoder == nil {
+		// the unix time of the snapshot, then the array
+		if _, err := r.r.ReadString('\n'); err != nil {
+			if err == io.EOF {
+				return Record{}, errors.New("snapshot: missing timestamp line")
+			}
+			return Record{}, err
+		}
+		r.decoder = json.NewDecoder(r.r)
+		if tok, err := r.decoder.Token(); err != nil || tok != json.Delim('[') {
+			return Record{}, errors.New("snapshot: expected a JSON array")
+		}
+	}
+	if !r.decoder.More() {
+		return Record{}, io.EOF
+	}
+	var e snapshotEntry
+	if err := r.decoder.Decode(&e); err != nil {
+		return Record{}, fmt.Errorf("snapshot: %v", err)
+	}
+	return Record{Key: e.Key, Value: e.Value, Deleted: e.Deleted}, nil
+}
+
+// snapshotWriter stamps entries without version with the time of the export,
+// as if they had been written then
+type snapshotWriter struct {
+	w       *bufio.Writer
+	version int64
+	count   int
+}
+
+func (w *snapshotWriter) Write(r Record) error {
+	if w.version == 0 {
+		w.version = time.Now().UnixNano()
+		if _, err := fmt.Fprintf(w.w, "%d\n[", time.Now().Unix()); err != nil {
+			return err
+		}
+	}
+	version := r.Version
+	if version == 0 {
+		version = w.version
+	}
+	b, err := json.Marshal(snapshotEntry{Key: r.Key, Value: r.Value, Version: version, Deleted: r.Deleted})
+	if err != nil {
+		return err
+	}
+	if w.count > 0 {
+		w.w.WriteByte(',')
+	}
+	w.count++
+	_, err = w.w.Write(b)
+	return err
+}
+
+func (w *snapshotWriter) Close() error {
+	if w.version == 0 {
+		fmt.Fprintf(w.w, "%d\n[", time.Now().Unix())
+	}
+	w.w.WriteString("]\n")
+	return w.w.Flush()
+}
+
+// FormatLogRecord lays out r as a line of the log format, newline included:
+// version "key" "value" done, key and value quoted as Go strings so they may
+// hold commas and newlines, and a delete ending in deleted instead of done.
+// Replicas, if any, goes before the status: version "key" "value" 3 done.
+// The trailing done tells a complete record from one cut short by a crash.
+func FormatLogRecord(r Record) string {
+	status := "done"
+	if r.Deleted {
+		status, r.Value = "deleted", ""
+	}
+	if r.Replicas > 0 {
+		status = strconv.FormatUint(uint64(r.Replicas), 10) + " " + status
+	}
+	return strconv.FormatInt(r.Version, 10) + " " + strconv.Quote(r.Key) + " " + strconv.Quote(r.Value) + " " + status + "\n"
+}
+
+// ParseLogRecord parses a line of the log format, with or without its
+// newline. It also reads the version,key,value,done lines written before keys
+// and values were quoted. A record cut short is not ok.
+func ParseLogRecord(line string) (Record, bool) {
+	line = strings.TrimSuffix(line, "\n")
+	if i := strings.IndexByte(line, ' '); i > 0 && strings.Trim(line[:i], "0123456789") == "" {
+		return parseQuotedRecord(line[:i], line[i+1:])
+	}
+	arr := strings.Split(line, ",")
+	if len(arr) != 4 || (arr[3] != "done" && arr[3] != "deleted") {
+		return Record{}, false
+	}
+	version, _ := strconv.ParseInt(arr[0], 10, 64)
+	return Record{Key: arr[1], Value: arr[2], Version: version, Deleted: arr[3] == "deleted"}, true
+}
+
+func parseQuotedRecord(version string, rest string) (Record, bool) {
+	var fields [2]string
+	for i := range fields {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil || len(rest) == len(quoted) || rest[len(quoted)] != ' ' {
+			return Record{}, false
+		}
+		fields[i], _ = strconv.Unquote(quoted)
+		rest = rest[len(quoted)+1:]
+	}
+	var replicas uint64
+	if n, status, ok := strings.Cut(rest, " "); ok {
+		var err error
+		if replicas, err = strconv.ParseUint(n, 10, 32); err != nil || replicas < 1 {
+			return Record{}, false
+		}
+		rest = status
+	}
+	if rest != "done" && rest != "deleted" {
+		return Record{}, false
+	}
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return Record{}, false
+	}
+	return Record{Key: fields[0], Value: fields[1], Version: v, Deleted: rest == "deleted", Replicas: uint32(replicas)}, true
+}
+
+type logReader struct {
+	lines *lineScanner
+}
+
+// Read skips a last line that is not a complete record, the tail of a log
+// cut short by a crash, anywhere else such a line is an error
+func (r *logReader) Read() (Record, error) {
+	line, err := r.lines.next()
+	if err != nil {
+		return Record{}, err
+	}
+	rec, ok := ParseLogRecord(line)
+	if !ok {
+		n := r.lines.line
+		if _, err := r.lines.next(); err != nil {
+			return Record{}, err
+		}
+		return Record{}, fmt.Errorf("line %d: not a complete version \"key\" \"value\" done record", n)
+	}
+	return rec, nil
+}
+
+type logWriter struct {
+	w *bufio.Writer
+}
+
+func (w *logWriter) Write(r Record) error {
+	if r.Version == 0 {
+		r.Version = time.Now().UnixNano()
+	}
+	_, err := w.w.WriteString(FormatLogRecord(r))
+	return err
+}
+
+func (w *logWriter) Close() error {
+	return w.w.Flush()
+}
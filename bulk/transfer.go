@@ -0,0 +1,184 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
+)
+
+// DefaultBatchSize is how many Set calls an import keeps in flight.
+const DefaultBatchSize = 64
+
+// Progress is reported after every batch of an import.
+type Progress struct {
+	Records int64 // records stored so far, resumed ones included
+	Skipped int64 // records skipped because a checkpoint covered them
+	Bytes   int64 // key and value bytes sent by this run
+	Elapsed time.Duration
+}
+
+// ImportOptions tune Import, the zero value is usable.
+type ImportOptions struct {
+	BatchSize int
+	// Checkpoint names a file recording how far the import got, updated
+	// after every batch. An import finding it resumes after the records it
+	// covers, and removes it once done.
+	Checkpoint  string
+	Source      string // recorded in the checkpoint, resuming another source fails
+	Progress    func(Progress)
+	CallOptions []kvclient.CallOption
+}
+
+// Checkpoint is the content of the checkpoint file.
+type Checkpoint struct {
+	Source  string `json:"source"`
+	Records int64  `json:"records"`
+}
+
+func loadCheckpoint(filename string) (Checkpoint, error) {
+	var cp Checkpoint
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, err
+	}
+	err = json.Unmarshal(b, &cp)
+	return cp, err
+}
+
+// saveCheckpoint replaces the checkpoint in one step, a crash leaves the old one
+func saveCheckpoint(filename string, cp Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// Import stores every record read from r with batches of concurrent Set and
+// Delete calls, and returns the number of records stored. A batch is written
+// completely before the checkpoint moves past it, so a failed import resumed
+// with the same checkpoint stores every record at least once.
+func Import(ctx context.Context, c *kvclient.Client, r Reader, opts ImportOptions) (int64, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	var cp Checkpoint
+	if opts.Checkpoint != "" {
+		var err error
+		if cp, err = loadCheckpoint(opts.Checkpoint); err != nil {
+			return 0, fmt.Errorf("checkpoint %s: %v", opts.Checkpoint, err)
+		}
+		if cp.Records > 0 && cp.Source != opts.Source {
+			return 0, fmt.Errorf("checkpoint %s belongs to %s, not %s", opts.Checkpoint, cp.Source, opts.Source)
+		}
+		cp.Source = opts.Source
+	}
+
+	start := time.Now()
+	progress := Progress{Skipped: cp.Records, Records: cp.Records}
+	for i := int64(0); i < cp.Records; i++ {
+		if _, err := r.Read(); err != nil {
+			return progress.Records, fmt.Errorf("skipping the %d records of the checkpoint: %v", cp.Records, err)
+		}
+	}
+
+	batch := make([]Record, 0, opts.BatchSize)
+	for done := false; !done; {
+		batch = batch[:0]
+		for len(batch) < opts.BatchSize {
+			rec, err := r.Read()
+			if err == io.EOF {
+				done = true
+				break
+			}
+			if err != nil {
+				return progress.Records, err
+			}
+			batch = append(batch, rec)
+		}
+		if err := storeBatch(ctx, c, batch, opts.CallOptions); err != nil {
+			return progress.Records, err
+		}
+		progress.Records += int64(len(batch))
+		for _, rec := range batch {
+			progress.Bytes += int64(len(rec.Key) + len(rec.Value))
+		}
+		progress.Elapsed = time.Since(start)
+		if opts.Checkpoint != "" && len(batch) > 0 {
+			cp.Records = progress.Records
+			if err := saveCheckpoint(opts.Checkpoint, cp); err != nil {
+				return progress.Records, err
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+	if opts.Checkpoint != "" {
+		if err := os.Remove(opts.Checkpoint); err != nil && !os.IsNotExist(err) {
+			return progress.Records, err
+		}
+	}
+	return progress.Records, nil
+}
+
+// storeBatch sends the records concurrently and returns the first error. Only
+// the last record of a key is sent, concurrent calls could land in any order.
+func storeBatch(ctx context.Context, c *kvclient.Client, batch []Record, opts []kvclient.CallOption) error {
+	last := make(map[string]int, len(batch))
+	for i, rec := range batch {
+		last[rec.Key] = i
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(batch))
+	for i := range batch {
+		if last[batch[i].Key] != i {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if batch[i].Deleted {
+				errs[i] = c.Delete(ctx, batch[i].Key, opts...)
+			} else {
+				errs[i] = c.Set(ctx, batch[i].Key, batch[i].Value, opts...)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export writes every key starting with prefix to w with a scan, and returns
+// the number of records written. w is closed, completing the file, only if
+// the scan went through.
+func Export(ctx context.Context, c *kvclient.Client, prefix string, w Writer, opts ...kvclient.CallOption) (int64, error) {
+	var count int64
+	err := c.Scan(ctx, prefix, func(key string, value string) error {
+		count++
+		return w.Write(Record{Key: key, Value: value})
+	}, append([]kvclient.CallOption{kvclient.Timeout(0)}, opts...)...)
+	if err != nil {
+		return count, err
+	}
+	return count, w.Close()
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
+)
+
+// opSource hands out the operations of a benchmark
+type opSource interface {
+	next() node
+}
+
+// datasetSource is the original benchmark: random keys of the dataset, read
+// only or 50% reads and 50% writes
+type datasetSource struct {
+	dataset []JsonData
+	modeRW  string
+	rng     *rand.Rand
+}
+
+func (d *datasetSource) next() node {
+	index := d.rng.Intn(len(d.dataset))
+	if d.modeRW == "rw" {
+		return node{key: d.dataset[index].Key, value: d.dataset[index].Value, action: d.rng.Intn(2)}
+	}
+	return node{key: d.dataset[index].Key, action: actionGet}
+}
+
+// recorder keeps the latencies of one benchmark loop, the reporter takes
+// the interval histograms from it every -report_interval
+type recorder struct {
+	lock           sync.Mutex
+	total          [numActions]histogram
+	interval       [numActions]histogram
+	errors         [numActions]int64
+	intervalErrors [numActions]int64
+}
+
+func (r *recorder) record(action int, d time.Duration, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.total[action].record(d)
+	r.interval[action].record(d)
+	if err != nil {
+		r.errors[action]++
+		r.intervalErrors[action]++
+	}
+}
+
+// takeInterval adds the interval histograms to hists and starts new ones
+func (r *recorder) takeInterval(hists *[numActions]histogram, errs *[numActions]int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for action := range r.interval {
+		hists[action].merge(&r.interval[action])
+		errs[action] += r.intervalErrors[action]
+		r.interval[action] = histogram{}
+		r.intervalErrors[action] = 0
+	}
+}
+
+// opSummary is the latency and throughput of one operation, or of all of
+// them, over an interval or the whole run
+type opSummary struct {
+	Operation  string  `json:"operation"`
+	Count      int64   `json:"count"`
+	Errors     int64   `json:"errors"`
+	Throughput float64 `json:"throughput"` // ops/sec
+	MeanUs     float64 `json:"mean_us"`
+	P50Us      float64 `json:"p50_us"`
+	P90Us      float64 `json:"p90_us"`
+	P99Us      float64 `json:"p99_us"`
+	P999Us     float64 `json:"p99_9_us"`
+	MaxUs      float64 `json:"max_us"`
+}
+
+type intervalReport struct {
+	TimeS      float64     `json:"time_s"` // end of the interval since the start of the run
+	Operations []opSummary `json:"operations"`
+}
+
+type benchmarkReport struct {
+	Workload   string           `json:"workload"`
+	Start      time.Time        `json:"start"`
+	DurationS  float64          `json:"duration_s"`
+	Operations []opSummary      `json:"operations"`
+	Intervals  []intervalReport `json:"intervals"`
+	Workers    []workerSummary  `json:"workers"`
+}
+
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
+
+func summarize(name string, h *histogram, errs int64, elapsed time.Duration) opSummary {
+	return opSummary{Operation: name, Count: h.count, Errors: errs, Throughput: float64(h.count) / elapsed.Seconds(),
+		MeanUs: micros(h.mean()), P50Us: micros(h.percentile(50)), P90Us: micros(h.percentile(90)),
+		P99Us: micros(h.percentile(99)), P999Us: micros(h.percentile(99.9)), MaxUs: micros(time.Duration(h.max))}
+}
+
+// summarizeAll summarizes every operation that ran, then all of them together
+func summarizeAll(hists *[numActions]histogram, errs *[numActions]int64, elapsed time.Duration) []opSummary {
+	var all histogram
+	var allErrs int64
+	summaries := []opSummary{}
+	for action := range hists {
+		if hists[action].count == 0 {
+			continue
+		}
+		summaries = append(summaries, summarize(actionNames[action], &hists[action], errs[action], elapsed))
+		all.merge(&hists[action])
+		allErrs += errs[action]
+	}
+	return append(summaries, summarize("all", &all, allErrs, elapsed))
+}
+
+func logSummary(prefix string, summaries []opSummary) {
+	for _, s := range summaries {
+		log.Printf("%s%s: %d ops (%d failed), %.0f ops/sec, latency mean %.0fus p50 %.0fus p90 %.0fus p99 %.0fus p99.9 %.0fus max %.0fus",
+			prefix, s.Operation, s.Count, s.Errors, s.Throughput, s.MeanUs, s.P50Us, s.P90Us, s.P99Us, s.P999Us, s.MaxUs)
+	}
+}
+
+// workerSummary is what one worker did over the whole run
+type workerSummary struct {
+	Worker     int     `json:"worker"`
+	Count      int64   `json:"count"`
+	Errors     int64   `json:"errors"`
+	Throughput float64 `json:"throughput"`
+	P99Us      float64 `json:"p99_us"`
+}
+
+// benchmarkConfig is how runBenchmark drives the load
+type benchmarkConfig struct {
+	name        string
+	workers     int
+	targetOps   float64 // open loop at this rate if positive, closed loop otherwise
+	opCount     int     // stop after this many operations if positive
+	duration    time.Duration
+	reportEvery time.Duration // 0 for no interval reports
+}
+
+// runBenchmark runs the operations of newSource with cfg.workers goroutines
+// until cfg.opCount operations are done or cfg.duration is over.
+//
+// In closed loop every worker issues its next operation as soon as the
+// previous one returns. In open loop one dispatcher schedules operations at
+// cfg.targetOps and the workers take them from a channel; latencies count
+// from the scheduled time, so a slow server is not hidden by workers that
+// send less (coordinated omission).
+func runBenchmark(client *kvclient.Client, newSource func(worker int) opSource, cfg benchmarkConfig) *benchmarkReport {
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+	recs := make([]*recorder, cfg.workers)
+	for i := range recs {
+		recs[i] = &recorder{}
+	}
+	report := &benchmarkReport{Workload: cfg.name, Start: time.Now()}
+
+	var intervalLock sync.Mutex
+	lastInterval := report.Start
+	takeInterval := func() {
+		intervalLock.Lock()
+		defer intervalLock.Unlock()
+		var hists [numActions]histogram
+		var errs [numActions]int64
+		for _, rec := range recs {
+			rec.takeInterval(&hists, &errs)
+		}
+		now := time.Now()
+		summaries := summarizeAll(&hists, &errs, now.Sub(lastInterval))
+		lastInterval = now
+		if summaries[len(summaries)-1].Count == 0 {
+			return
+		}
+		elapsed := now.Sub(report.Start)
+		report.Intervals = append(report.Intervals, intervalReport{TimeS: elapsed.Seconds(), Operations: summaries})
+		all := summaries[len(summaries)-1]
+		log.Printf("[%s] %.0f ops/sec, p50 %.0fus, p99 %.0fus, max %.0fus", elapsed.Truncate(time.Second), all.Throughput, all.P50Us, all.P99Us, all.MaxUs)
+	}
+	done := make(chan struct{})
+	var reporter sync.WaitGroup
+	if cfg.reportEvery > 0 {
+		reporter.Add(1)
+		go func() {
+			defer reporter.Done()
+			ticker := time.NewTicker(cfg.reportEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					takeInterval()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	deadline := report.Start.Add(cfg.duration)
+	issued := int64(0)
+	// claim reports whether another operation may start
+	claim := func() bool {
+		if time.Now().After(deadline) {
+			return false
+		}
+		return cfg.opCount <= 0 || atomic.AddInt64(&issued, 1) <= int64(cfg.opCount)
+	}
+
+	var wg sync.WaitGroup
+	if cfg.targetOps > 0 {
+		in := make(chan node, cfg.workers*1024)
+		for i := 0; i < cfg.workers; i++ {
+			wg.Add(1)
+			go sendrequest(client, in, recs[i], &wg)
+		}
+		src := newSource(0)
+		interval := time.Duration(float64(time.Second) / cfg.targetOps)
+		for i := int64(0); claim(); i++ {
+			n := src.next()
+			n.start = report.Start.Add(time.Duration(i) * interval)
+			if wait := time.Until(n.start); wait > 0 {
+				time.Sleep(wait)
+			}
+			in <- n
+		}
+		close(in)
+	} else {
+		for i := 0; i < cfg.workers; i++ {
+			wg.Add(1)
+			go func(rec *recorder, src opSource) {
+				defer wg.Done()
+				for claim() {
+					n := src.next()
+					start := time.Now()
+					err := execute(client, n)
+					rec.record(n.action, time.Since(start), err)
+				}
+			}(recs[i], newSource(i))
+		}
+	}
+	wg.Wait()
+	close(done)
+	reporter.Wait()
+	if cfg.reportEvery > 0 {
+		takeInterval()
+	}
+
+	elapsed := time.Since(report.Start)
+	report.DurationS = elapsed.Seconds()
+	var total [numActions]histogram
+	var errs [numActions]int64
+	for i, rec := range recs {
+		var all histogram
+		var allErrs int64
+		for action := range rec.total {
+			total[action].merge(&rec.total[action])
+			all.merge(&rec.total[action])
+			errs[action] += rec.errors[action]
+			allErrs += rec.errors[action]
+		}
+		report.Workers = append(report.Workers, workerSummary{Worker: i, Count: all.count, Errors: allErrs,
+			Throughput: float64(all.count) / elapsed.Seconds(), P99Us: micros(all.percentile(99))})
+	}
+	report.Operations = summarizeAll(&total, &errs, elapsed)
+	log.Printf("elapsed time: %s, workers: %d", elapsed, cfg.workers)
+	if cfg.workers > 1 {
+		for _, w := range report.Workers {
+			log.Printf("worker %d: %d ops (%d failed), %.0f ops/sec, p99 %.0fus", w.Worker, w.Count, w.Errors, w.Throughput, w.P99Us)
+		}
+	}
+	logSummary("", report.Operations)
+	return report
+}
+
+// writeReport saves the report as JSON, or as CSV with one row per operation
+// and interval followed by the totals
+func writeReport(report *benchmarkReport, filename string, format string) error {
+	if format == "" {
+		format = "json"
+		if strings.ToLower(filepath.Ext(filename)) == ".csv" {
+			format = "csv"
+		}
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	case "csv":
+		w := csv.NewWriter(file)
+		w.Write([]string{"workload", "section", "time_s", "operation", "count", "errors", "throughput",
+			"mean_us", "p50_us", "p90_us", "p99_us", "p99_9_us", "max_us"})
+		row := func(section string, t float64, s opSummary) {
+			f := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+			w.Write([]string{report.Workload, section, f(t), s.Operation, strconv.FormatInt(s.Count, 10), strconv.FormatInt(s.Errors, 10),
+				f(s.Throughput), f(s.MeanUs), f(s.P50Us), f(s.P90Us), f(s.P99Us), f(s.P999Us), f(s.MaxUs)})
+		}
+		for _, interval := range report.Intervals {
+			for _, s := range interval.Operations {
+				row("interval", interval.TimeS, s)
+			}
+		}
+		for _, s := range report.Operations {
+			row("total", report.DurationS, s)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown result format %q, want json or csv", format)
+	}
+	return file.Close()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -14,7 +15,10 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
 
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
 	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tlsconfig"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
 )
 
 // keep alive param
@@ -36,7 +40,8 @@ const (
 type node struct {
 	key    string
 	value  string
-	action int // 0: set; 1: get; 2:getPrefix
+	action int       // actionGet, actionSet, actionGetPrefix, ...
+	start  time.Time // when an open loop benchmark scheduled it
 }
 
 var COUNT int = 1000
@@ -48,79 +53,183 @@ var modeRW = "r"
 var datasetFile = "KV_10k_128B_512B.txt"
 var exp_time = 60
 var maxMsgSize = 1024 * 1024 * 10
+var readMode = "linearizable"
+var maxStalenessMs int64 = 0
+var quorumN, quorumR, quorumW uint
+var endpoints = ""
+var connsPerEndpoint = 1
+var balancer = "round_robin"
+var cacheBytes int64 = 0
+var workloadName = ""
+var proportions = [numActions]float64{-1, -1, -1, -1, -1}
+var requestDist = ""
+var recordCount int64 = 1000
+var sizeDist = "constant"
+var maxScanLength = 100
+var phase = "both"
+var reportInterval = 10
+var workers = 1
+var targetOps float64 = 0
+var resultFile = ""
+var resultFormat = ""
+var cacheStalenessMs int64 = 0
+var useTLS = false
+var tlsCA = ""
+var tlsCert = ""
+var tlsKey = ""
+var token = ""
+var namespace = ""
+var traceExporter = ""
+var traceEndpoint = tracing.DefaultEndpoint
+var traceSampleRatio float64 = 1
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
-	flag.IntVar(&COUNT, "count", COUNT, "ops total count")
+	flag.IntVar(&COUNT, "count", COUNT, "ops total count of the -workload run phase, 0 to run for -exp_time")
 	flag.IntVar(&valueSize, "size", valueSize, "value size")
 	flag.IntVar(&port, "p", port, "the target server's port")
 	flag.IntVar(&exp_time, "exp_time", exp_time, "total experiment time")
 	flag.StringVar(&serverIp, "ip", serverIp, "the target server's ip address")
-	flag.StringVar(&mode, "mode", mode, "the mode of client, interative or benchmark")
+	flag.StringVar(&mode, "mode", mode, "the mode of client, interative, benchmark, test, verify, members or stats")
 	flag.StringVar(&datasetFile, "dataset", datasetFile, "dataset for benchmark, e.g. KV_10k_128B_512B.txt")
 	flag.StringVar(&modeRW, "modeRW", modeRW, "the mode of client action, `r` for readonly, `rw` for 50% read 50% write")
+	flag.StringVar(&readMode, "read", readMode, "read consistency, `linearizable`, `bounded` or `any`")
+	flag.Int64Var(&maxStalenessMs, "max_staleness_ms", maxStalenessMs, "staleness bound in ms for `bounded` reads")
+	flag.UintVar(&quorumN, "n", quorumN, "number of replicas to keep each key on, 0 keeps keys on the target server only")
+	flag.UintVar(&quorumR, "r", quorumR, "replicas a get waits for when -n is set")
+	flag.UintVar(&quorumW, "w", quorumW, "replicas a set waits for when -n is set")
+	flag.StringVar(&endpoints, "endpoints", endpoints, "comma separated servers to spread requests over, e.g. host1:6000,host2:6000, overrides -ip and -p")
+	flag.IntVar(&connsPerEndpoint, "conns", connsPerEndpoint, "connections per server")
+	flag.StringVar(&balancer, "balancer", balancer, "how requests are spread, `round_robin` or `least_loaded`")
+	flag.Int64Var(&cacheBytes, "cache_bytes", cacheBytes, "size of the client side read cache in bytes, 0 disables it")
+	flag.Int64Var(&cacheStalenessMs, "cache_staleness_ms", cacheStalenessMs, "max age in ms of a cached value, 0 for the default of 5000")
+	flag.StringVar(&workloadName, "workload", workloadName, "YCSB core workload `a` to `f`, or `custom`, for benchmark mode instead of -dataset and -modeRW")
+	for action, name := range actionNames {
+		flag.Float64Var(&proportions[action], name+"_proportion", -1, "share of "+name+" operations, -1 keeps the one of -workload")
+	}
+	flag.StringVar(&requestDist, "request_distribution", requestDist, "how keys are picked, `uniform`, `zipfian` or `latest`, default of -workload if empty")
+	flag.Int64Var(&recordCount, "record_count", recordCount, "keys inserted by the load phase of -workload")
+	flag.StringVar(&sizeDist, "value_size_distribution", sizeDist, "value sizes of -workload, `constant` (-size), `uniform` or `zipfian` (1 to -size)")
+	flag.IntVar(&maxScanLength, "max_scan_length", maxScanLength, "about how many keys a scan of -workload reads")
+	flag.StringVar(&phase, "phase", phase, "phase of -workload, `load`, `run` or `both`")
+	flag.IntVar(&workers, "workers", workers, "goroutines sending requests in benchmark mode, spread over the -conns connections")
+	flag.Float64Var(&targetOps, "target_ops", targetOps, "open loop: schedule this many ops/sec whatever the latency, 0 for closed loop")
+	flag.IntVar(&reportInterval, "report_interval", reportInterval, "seconds between throughput and latency reports of benchmark mode, 0 for none")
+	flag.StringVar(&resultFile, "result_file", resultFile, "write the latency histograms and throughput of benchmark mode to this file")
+	flag.StringVar(&resultFormat, "result_format", resultFormat, "format of -result_file, `json` or `csv`, guessed from its extension if empty")
+	flag.BoolVar(&useTLS, "tls", useTLS, "dial the servers over TLS, implied by -tls_ca and -tls_cert")
+	flag.StringVar(&tlsCA, "tls_ca", tlsCA, "PEM CA certificates verifying the servers, the system roots if empty")
+	flag.StringVar(&tlsCert, "tls_cert", tlsCert, "PEM client certificate for servers requiring one, enables TLS, reloaded when the file changes")
+	flag.StringVar(&tlsKey, "tls_key", tlsKey, "PEM private key of -tls_cert")
+	flag.StringVar(&token, "token", token, "bearer token for servers started with -auth_tokens")
+	flag.StringVar(&namespace, "namespace", namespace, "namespace of the keys, the default namespace if empty")
+	flag.StringVar(&traceExporter, "trace_exporter", traceExporter, "where OpenTelemetry spans go, `stdout` or `otlp`, tracing is off if empty")
+	flag.StringVar(&traceEndpoint, "trace_endpoint", traceEndpoint, "OTLP/HTTP traces URL of the collector for -trace_exporter otlp")
+	flag.Float64Var(&traceSampleRatio, "trace_sample_ratio", traceSampleRatio, "share of the calls that are traced, 0 to 1")
 	flag.Parse()
 
-	conn, err := grpc.Dial(serverIp+":"+strconv.Itoa(port),
-		grpc.WithInsecure(),
-		grpc.WithKeepaliveParams(kacp),
-		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize), grpc.MaxCallSendMsgSize(maxMsgSize)))
+	stopTracing, err := tracing.Setup(tracing.Config{Service: "kvclient", Exporter: traceExporter, Endpoint: traceEndpoint, SampleRatio: traceSampleRatio})
 	if err != nil {
-		log.Fatalf("failed to connect to server: %s", err)
+		log.Fatalf("failed to set up tracing: %s", err)
 	}
-	defer conn.Close()
-	client := pb.NewKVStoreClient(conn)
+	defer stopTracing(context.Background())
 
-	if mode == "benchmark" {
-		var opsCount = make([]int, 3)
-		dataset := LoadFromHistoryLog(datasetFile)
-		start := time.Now()
-
-		timeout := time.After(time.Duration(exp_time) * time.Second)
-		for {
-			select {
-			case <-timeout:
-				info := fmt.Sprintf("elapsed time: %s, #total_gets: %d, #total_sets: %d, #total_getprefixes: %d, #total_ops: %d",
-					time.Since(start), opsCount[0], opsCount[1], opsCount[2], opsCount[0]+opsCount[1]+opsCount[2])
-				log.Print(info) // benchmark time
-				return
-			default:
-				var n node
-				index := rand.Intn(len(dataset))
+	if quorumN > 0 {
+		quorumOpts = &pb.Quorum{N: uint32(quorumN), R: uint32(quorumR), W: uint32(quorumW)}
+	}
+	if err := parseReadOptions(readMode, maxStalenessMs); err != nil {
+		log.Fatalf("invalid read options: %s", err)
+	}
 
-				if modeRW == "r" {
-					n = node{key: dataset[index].Key, action: 0}
-				} else if modeRW == "rw" {
-					n = node{key: dataset[index].Key, value: dataset[index].Value, action: rand.Intn(2)}
-				}
-				opsCount[n.action]++
+	target := serverIp + ":" + strconv.Itoa(port)
+	var extra []string
+	if endpoints != "" {
+		target, extra = "", strings.Split(endpoints, ",")
+	}
+	lb := kvclient.RoundRobin
+	switch balancer {
+	case "round_robin":
+	case "least_loaded":
+		lb = kvclient.LeastLoaded
+	default:
+		log.Fatalf("invalid balancer: %s", balancer)
+	}
 
-				switch n.action {
-				case 0:
-					_, err := getKey(client, n.key)
-					if err != nil {
-						log.Printf("err: %s\n", err)
-					}
-				case 1:
-					err := setKey(client, n.key, n.value)
-					if err != nil {
-						log.Printf("err: %s\n", err)
-					}
-				case 2:
-					_, err := getPrefixKey(client, n.key)
-					if err != nil {
-						log.Printf("err: %s\n", err)
-					}
-				default:
-					log.Fatal("n.action error")
-				}
+	var clientOptions []kvclient.Option
+	if useTLS || tlsCA != "" || tlsCert != "" {
+		certs, err := tlsconfig.NewReloader(tlsconfig.Files{CertFile: tlsCert, KeyFile: tlsKey, CAFile: tlsCA}, tlsconfig.DefaultReloadInterval)
+		if err != nil {
+			log.Fatalf("failed to load the TLS certificates: %s", err)
+		}
+		defer certs.Close()
+		clientOptions = append(clientOptions, kvclient.WithTLS(certs.ClientConfig()))
+	}
+	if token != "" {
+		clientOptions = append(clientOptions, kvclient.WithToken(token))
+	}
+	if namespace != "" {
+		clientOptions = append(clientOptions, kvclient.WithNamespace(namespace))
+	}
+	client, err := kvclient.New(target, append(clientOptions,
+		kvclient.WithEndpoints(extra...),
+		kvclient.WithConnsPerEndpoint(connsPerEndpoint),
+		kvclient.WithBalancer(lb),
+		kvclient.WithCache(cacheBytes, time.Duration(cacheStalenessMs)*time.Millisecond),
+		kvclient.WithDialOptions(
+			grpc.WithKeepaliveParams(kacp),
+			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize), grpc.MaxCallSendMsgSize(maxMsgSize))),
+		kvclient.WithDefaultCallOptions(kvclient.ReadOptions(readOpts), kvclient.Quorum(quorumOpts)))...)
+	if err != nil {
+		log.Fatalf("failed to connect to server: %s", err)
+	}
+	defer client.Close()
 
+	if mode == "benchmark" {
+		if resultFormat != "" && resultFormat != "json" && resultFormat != "csv" {
+			log.Fatalf("invalid result format: %s", resultFormat)
+		}
+		cfg := benchmarkConfig{name: "dataset " + datasetFile + " " + modeRW, workers: workers, targetOps: targetOps,
+			duration: time.Duration(exp_time) * time.Second, reportEvery: time.Duration(reportInterval) * time.Second}
+		seed := time.Now().UnixNano()
+		var newSource func(worker int) opSource
+		if workloadName != "" {
+			w, err := newWorkload(workloadName, proportions, requestDist, recordCount, valueSize, sizeDist, maxScanLength)
+			if err != nil {
+				log.Fatalf("invalid workload: %s", err)
+			}
+			if phase != "load" && phase != "run" && phase != "both" {
+				log.Fatalf("invalid phase: %s", phase)
+			}
+			if phase == "load" || phase == "both" {
+				loadPhase(client, w, workers, seed)
+			}
+			if phase == "load" {
+				return
+			}
+			newSource = func(worker int) opSource { return w.generator(seed + int64(worker)) }
+			cfg.name, cfg.opCount = "ycsb "+workloadName, COUNT
+		} else {
+			dataset := LoadFromHistoryLog(datasetFile)
+			newSource = func(worker int) opSource {
+				return &datasetSource{dataset: dataset, modeRW: modeRW, rng: rand.New(rand.NewSource(seed + int64(worker)))}
+			}
+		}
+		report := runBenchmark(client, newSource, cfg)
+		if cacheBytes > 0 {
+			stats := client.CacheStats()
+			log.Printf("cache hits: %d, misses: %d, evictions: %d, invalidations: %d, entries: %d, bytes: %d",
+				stats.Hits, stats.Misses, stats.Evictions, stats.Invalidations, stats.Entries, stats.Bytes)
+		}
+		if resultFile != "" {
+			if err := writeReport(report, resultFile, resultFormat); err != nil {
+				log.Fatalf("failed to write %s: %s", resultFile, err)
 			}
+			log.Printf("results written to %s", resultFile)
 		}
 	} else if mode == "interactive" {
 		reader := bufio.NewReader(os.Stdin)
 		for {
-			fmt.Print("> set, get or getPrefix (i.e. set key value): ")
+			fmt.Print("> set, get, getPrefix or delete (i.e. set key value): ")
 			text, err := reader.ReadString('\n')
 			if err != nil {
 				fmt.Printf("failed to read from stdin: %s\n", err)
@@ -164,10 +273,56 @@ func main() {
 				}
 				log.Printf("successfully get %s \n", values)
 
+			case "delete":
+				if err := client.Delete(context.Background(), items[1]); err != nil {
+					log.Printf("failed to delete from server: %s\n", err)
+					continue
+				}
+				log.Println("successfully deleted")
+
 			default:
 				continue
 			}
 		}
+	} else if mode == "verify" {
+		reports, err := client.VerifyReplicas(context.Background(), nil, kvclient.Timeout(60*time.Second))
+		if err != nil {
+			log.Fatalf("failed to verify replicas: %s", err)
+		}
+		for _, report := range reports {
+			if report.GetError() != "" {
+				log.Printf("peer %s: %s\n", report.GetPeer(), report.GetError())
+				continue
+			}
+			log.Printf("peer %s: in sync %v, %d keys differ\n", report.GetPeer(), report.GetInSync(), len(report.GetDiffs()))
+			for _, diff := range report.GetDiffs() {
+				log.Printf("  key %s local version %d remote version %d\n", diff.GetKey(), diff.GetLocalVersion(), diff.GetRemoteVersion())
+			}
+		}
+	} else if mode == "members" {
+		members, err := client.Members(context.Background())
+		if err != nil {
+			log.Fatalf("failed to list members: %s", err)
+		}
+		for _, m := range members {
+			log.Printf("member %s: %s since %s, last seen %s\n", m.GetAddr(), m.GetState(),
+				time.Unix(0, m.GetSince()).Format(time.RFC3339), time.Unix(0, m.GetLastSeen()).Format(time.RFC3339))
+		}
+	} else if mode == "stats" {
+		stats, err := client.Stats(context.Background())
+		if err != nil {
+			log.Fatalf("failed to get stats: %s", err)
+		}
+		log.Printf("server start time: %s, uptime %s, recovered in %s\n", time.Unix(0, stats.GetStartTime()).Format(time.RFC3339),
+			time.Duration(stats.GetUptimeMs())*time.Millisecond, time.Duration(stats.GetRecoveryMs())*time.Millisecond)
+		log.Printf("#total_sets: %d, #total_gets: %d, #total_getprefixes: %d, #total_deletes: %d, #total_scans: %d, #total_watches: %d\n",
+			stats.GetSets(), stats.GetGets(), stats.GetGetPrefixes(), stats.GetDeletes(), stats.GetScans(), stats.GetWatches())
+		lastSnap := "never"
+		if stats.GetLastSnapTime() > 0 {
+			lastSnap = time.Unix(stats.GetLastSnapTime(), 0).Format(time.RFC3339)
+		}
+		log.Printf("keys: %d, data bytes: %d, wal size: %d, revision: %d, last snapshot: %s\n",
+			stats.GetKeys(), stats.GetDataBytes(), stats.GetWalSize(), stats.GetRevision(), lastSnap)
 	} else if mode == "test" {
 		var opsCount = make([]int, 3)
 		timeout := time.After(time.Duration(exp_time) * time.Second)
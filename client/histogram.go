@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision of the histogram: values are kept with 7
+// significant bits, less than 1% error, like an HDR histogram with 2
+// significant digits
+const subBucketBits = 7
+
+const (
+	subBuckets     = 1 << subBucketBits
+	halfSubBuckets = subBuckets / 2
+	numBuckets     = subBuckets + (64-subBucketBits)*halfSubBuckets
+)
+
+// histogram records latencies in log-linear buckets, constant memory and
+// constant time whatever the range. It is not safe for concurrent use.
+type histogram struct {
+	counts [numBuckets]int64
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func bucketIndex(v int64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - subBucketBits
+	return subBuckets + (shift-1)*halfSubBuckets + int(v>>uint(shift)) - halfSubBuckets
+}
+
+// bucketHigh returns the highest value counted in the bucket
+func bucketHigh(i int) int64 {
+	if i < subBuckets {
+		return int64(i)
+	}
+	shift := uint((i-subBuckets)/halfSubBuckets + 1)
+	sub := int64((i-subBuckets)%halfSubBuckets + halfSubBuckets)
+	return (sub+1)<<shift - 1
+}
+
+func (h *histogram) record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[bucketIndex(v)]++
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) merge(o *histogram) {
+	if o.count == 0 {
+		return
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+	h.count += o.count
+	h.sum += o.sum
+}
+
+// percentile returns the latency q (0 to 100) percent of the values are at or below
+func (h *histogram) percentile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(q / 100 * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+	seen := int64(0)
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			if high := bucketHigh(i); high < h.max {
+				return time.Duration(high)
+			}
+			return time.Duration(h.max)
+		}
+	}
+	return time.Duration(h.max)
+}
+
+func (h *histogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / h.count)
+}
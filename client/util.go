@@ -1,16 +1,17 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"os"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/ss87021456/gRPC-KVStore/bulk"
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
 	pb "github.com/ss87021456/gRPC-KVStore/proto"
 )
 
@@ -18,58 +19,66 @@ type JsonData struct {
 	Key, Value string
 }
 
-func getKey(client pb.KVStoreClient, key string) (string, error) {
-	// log.Printf("Getting key: %s", key)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// read options attached to every get and getPrefix call
+var readOpts = &pb.ReadOptions{}
 
-	result, err := client.Get(ctx, &pb.GetRequest{Key: key})
-	if err != nil {
-		return "", fmt.Errorf("failed to get key: %s: %v,", key, err)
+// quorum attached to every get and set call, nil keeps keys on the target server
+var quorumOpts *pb.Quorum
+
+func parseReadOptions(readMode string, maxStalenessMs int64) error {
+	switch readMode {
+	case "linearizable":
+		readOpts = &pb.ReadOptions{Consistency: pb.ReadConsistency_LINEARIZABLE}
+	case "bounded":
+		readOpts = &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MaxStalenessMs: maxStalenessMs}
+	case "any":
+		readOpts = &pb.ReadOptions{Consistency: pb.ReadConsistency_ANY}
+	default:
+		return fmt.Errorf("unknown read mode %s", readMode)
 	}
-	return result.GetValue(), nil
+	return nil
 }
 
-func setKey(client pb.KVStoreClient, key string, value string) error {
-	// log.Printf("Setting key: %s, value: %d", key, len(value))
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func getKey(client *kvclient.Client, key string) (string, error) {
+	return client.Get(context.Background(), key)
+}
 
-	_, err := client.Set(ctx, &pb.SetRequest{Key: key, Value: value})
-	if err != nil {
-		return fmt.Errorf("failed to set key: %s, with error: %s", key, err)
-	}
-	return nil
+func setKey(client *kvclient.Client, key string, value string) error {
+	return client.Set(context.Background(), key, value)
 }
 
-func getPrefixKey(client pb.KVStoreClient, key string) ([]string, error) {
-	// log.Printf("Get Prefix key: %s", key)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func getPrefixKey(client *kvclient.Client, key string) ([]string, error) {
+	return client.GetPrefix(context.Background(), key)
+}
 
-	result, err := client.GetPrefix(ctx, &pb.GetPrefixRequest{Key: key})
-	if err != nil {
-		return []string{}, fmt.Errorf("failed to get prefix key: %s: ", key)
+// execute runs the operation of the node, a read-modify-write reads the key
+// then stores the new value
+func execute(client *kvclient.Client, n node) error {
+	var err error
+	switch n.action {
+	case actionGet:
+		_, err = getKey(client, n.key)
+	case actionSet, actionInsert:
+		err = setKey(client, n.key, n.value)
+	case actionGetPrefix:
+		_, err = getPrefixKey(client, n.key)
+	case actionReadModifyWrite:
+		if _, err = getKey(client, n.key); err == nil {
+			err = setKey(client, n.key, n.value)
+		}
+	default:
+		log.Fatal("n.action error")
 	}
-	return result.GetValues(), nil
+	return err
 }
 
-func sendrequest(client pb.KVStoreClient, in <-chan node, wg *sync.WaitGroup) {
+// sendrequest executes the nodes of an open loop benchmark, their latency
+// counts from when they were scheduled to start
+func sendrequest(client *kvclient.Client, in <-chan node, rec *recorder, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for n := range in {
-		switch n.action {
-		case 0:
-			getKey(client, n.key)
-		case 1:
-			err := setKey(client, n.key, n.value)
-			if err != nil {
-				log.Printf("err: %s\n", err)
-			}
-		case 2:
-			getPrefixKey(client, n.key)
-		default:
-			log.Fatal("n.action error")
-		}
+		err := execute(client, n)
+		rec.record(n.action, time.Since(n.start), err)
 	}
 }
 
@@ -81,15 +90,21 @@ func LoadFromHistoryLog(filename string) []JsonData {
 	defer file.Close()
 
 	var dataset []JsonData
-	scanner := bufio.NewScanner(file)
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 1024*1024*5)
-	for scanner.Scan() {
-		arr := strings.Split(scanner.Text(), ",")
-		dataset = append(dataset, JsonData{Key: arr[1], Value: arr[2]})
+	reader, _ := bulk.NewReader(file, "log")
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to parse dataset %s: %s", filename, err)
+		}
+		if !rec.Deleted {
+			dataset = append(dataset, JsonData{Key: rec.Key, Value: rec.Value})
+		}
 	}
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+	if len(dataset) == 0 {
+		log.Fatalf("dataset %s holds no key", filename)
 	}
 	log.Printf("done parsing dataset %s with dataset size %d\n", filename, len(dataset))
 	return dataset
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
+)
+
+// actions of a node, the first three are the ones of the original benchmark
+const (
+	actionGet = iota
+	actionSet
+	actionGetPrefix
+	actionInsert
+	actionReadModifyWrite
+	numActions
+)
+
+var actionNames = []string{"read", "update", "scan", "insert", "read_modify_write"}
+
+// proportions of the YCSB core workloads, in the order of the actions
+var workloads = map[string][numActions]float64{
+	"a":      {0.5, 0.5, 0, 0, 0},   // update heavy
+	"b":      {0.95, 0.05, 0, 0, 0}, // read mostly
+	"c":      {1, 0, 0, 0, 0},       // read only
+	"d":      {0.95, 0, 0, 0.05, 0}, // read latest
+	"e":      {0, 0, 0.95, 0.05, 0}, // short ranges
+	"f":      {0.5, 0, 0, 0, 0.5},   // read-modify-write
+	"custom": {},                    // only the proportions given with flags
+}
+
+// default request distribution of each workload
+var workloadDistributions = map[string]string{"a": "zipfian", "b": "zipfian", "c": "zipfian", "d": "latest", "e": "zipfian", "f": "zipfian"}
+
+const zipfianConstant = 0.99
+
+// zipfian draws items in [0, n) with item 0 the most popular, following
+// "Quickly Generating Billion-Record Synthetic Databases" by Gray et al. as
+// YCSB does. n may grow, zeta is then extended incrementally.
+type zipfian struct {
+	n     int64
+	theta float64
+	alpha float64
+	zetan float64
+	zeta2 float64
+	eta   float64
+}
+
+func zeta(from int64, to int64, theta float64, sum float64) float64 {
+	for i := from; i < to; i++ {
+		sum += 1 / math.Pow(float64(i+1), theta)
+	}
+	return sum
+}
+
+func newZipfian(n int64) *zipfian {
+	z := &zipfian{theta: zipfianConstant}
+	z.alpha = 1 / (1 - z.theta)
+	z.zeta2 = zeta(0, 2, z.theta, 0)
+	z.grow(n)
+	return z
+}
+
+func (z *zipfian) grow(n int64) {
+	if n <= z.n {
+		return
+	}
+	z.zetan = zeta(z.n, n, z.theta, z.zetan)
+	z.n = n
+	z.eta = (1 - math.Pow(2/float64(n), 1-z.theta)) / (1 - z.zeta2/z.zetan)
+}
+
+func (z *zipfian) next(rng *rand.Rand) int64 {
+	u := rng.Float64()
+	uz := u * z.zetan
+	if uz < 1 {
+		return 0
+	}
+	if uz < 1+math.Pow(0.5, z.theta) {
+		return 1
+	}
+	return int64(float64(z.n) * math.Pow(z.eta*u-z.eta+1, z.alpha))
+}
+
+// scramble spreads the popular items of a zipfian over the keyspace, so hot
+// keys are not neighbours
+func scramble(item int64, n int64) int64 {
+	h := fnv.New64a()
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(item >> (8 * uint(i)))
+	}
+	h.Write(b[:])
+	return int64(h.Sum64() % uint64(n))
+}
+
+// workload generates the operations of a YCSB core workload. Keys are
+// user0000000000, user0000000001, ... so that a scan is a prefix of a key
+// with its last digits dropped.
+type workload struct {
+	proportions  [numActions]float64
+	distribution string
+	maxValueSize int
+	sizeDist     string
+	scanDigits   int
+	records      int64 // keys inserted so far, shared by every generator
+}
+
+const keyDigits = 10
+
+func workloadKey(i int64) string {
+	return fmt.Sprintf("user%0*d", keyDigits, i)
+}
+
+// newWorkload checks the settings, proportions of -1 take the preset of the workload
+func newWorkload(name string, proportions [numActions]float64, distribution string, records int64,
+	maxValueSize int, sizeDist string, maxScanLength int) (*workload, error) {
+	w := &workload{distribution: distribution, maxValueSize: maxValueSize, sizeDist: sizeDist, records: records}
+	preset, ok := workloads[strings.ToLower(name)]
+	if name != "" && !ok {
+		return nil, fmt.Errorf("unknown workload %q, want a to f", name)
+	}
+	total := 0.0
+	for i, p := range proportions {
+		if p < 0 {
+			p = preset[i]
+		}
+		w.proportions[i] = p
+		total += p
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("every operation proportion is 0")
+	}
+	for i := range w.proportions {
+		w.proportions[i] /= total
+	}
+	if w.distribution == "" {
+		w.distribution = workloadDistributions[strings.ToLower(name)]
+		if w.distribution == "" {
+			w.distribution = "uniform"
+		}
+	}
+	switch w.distribution {
+	case "uniform", "zipfian", "latest":
+	default:
+		return nil, fmt.Errorf("unknown request distribution %q, want uniform, zipfian or latest", w.distribution)
+	}
+	switch sizeDist {
+	case "constant", "uniform", "zipfian":
+	default:
+		return nil, fmt.Errorf("unknown value size distribution %q, want constant, uniform or zipfian", sizeDist)
+	}
+	if records <= 0 || maxValueSize <= 0 {
+		return nil, fmt.Errorf("record count and value size must be positive")
+	}
+	for n := 1; n < maxScanLength && w.scanDigits < keyDigits; n *= 10 {
+		w.scanDigits++
+	}
+	return w, nil
+}
+
+// generator draws the operations of a workload, one per goroutine
+type generator struct {
+	w        *workload
+	rng      *rand.Rand
+	keys     *zipfian
+	sizes    *zipfian
+	cumulate [numActions]float64
+}
+
+func (w *workload) generator(seed int64) *generator {
+	g := &generator{w: w, rng: rand.New(rand.NewSource(seed))}
+	sum := 0.0
+	for i, p := range w.proportions {
+		sum += p
+		g.cumulate[i] = sum
+	}
+	if w.distribution != "uniform" {
+		g.keys = newZipfian(atomic.LoadInt64(&w.records))
+	}
+	if w.sizeDist == "zipfian" {
+		g.sizes = newZipfian(int64(w.maxValueSize))
+	}
+	return g
+}
+
+// chooseKey returns the index of an existing key
+func (g *generator) chooseKey() int64 {
+	n := atomic.LoadInt64(&g.w.records)
+	switch g.w.distribution {
+	case "zipfian":
+		g.keys.grow(n)
+		return scramble(g.keys.next(g.rng), n)
+	case "latest":
+		g.keys.grow(n)
+		i := n - 1 - g.keys.next(g.rng)
+		if i < 0 {
+			i = 0
+		}
+		return i
+	}
+	return g.rng.Int63n(n)
+}
+
+func (g *generator) value() string {
+	size := g.w.maxValueSize
+	switch g.w.sizeDist {
+	case "uniform":
+		size = 1 + g.rng.Intn(g.w.maxValueSize)
+	case "zipfian":
+		size = 1 + int(g.sizes.next(g.rng))
+	}
+	return randString(g.rng, size)
+}
+
+// next returns the next operation of the run phase
+func (g *generator) next() node {
+	u := g.rng.Float64()
+	action := actionGet
+	for action < numActions-1 && u >= g.cumulate[action] {
+		action++
+	}
+	switch action {
+	case actionInsert:
+		return node{key: workloadKey(atomic.AddInt64(&g.w.records, 1) - 1), value: g.value(), action: actionInsert}
+	case actionGetPrefix:
+		key := workloadKey(g.chooseKey())
+		return node{key: key[:len(key)-g.w.scanDigits], action: actionGetPrefix}
+	case actionSet, actionReadModifyWrite:
+		return node{key: workloadKey(g.chooseKey()), value: g.value(), action: action}
+	}
+	return node{key: workloadKey(g.chooseKey()), action: actionGet}
+}
+
+// randString is RandStringBytesMaskImpr with a source of its own
+func randString(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i, cache, remain := n-1, rng.Int63(), letterIdxMax; i >= 0; {
+		if remain == 0 {
+			cache, remain = rng.Int63(), letterIdxMax
+		}
+		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
+			b[i] = letterBytes[idx]
+			i--
+		}
+		cache >>= letterIdxBits
+		remain--
+	}
+	return string(b)
+}
+
+// loadPhase inserts the keys the run phase reads, split over the workers
+func loadPhase(client *kvclient.Client, w *workload, workers int, seed int64) {
+	if workers < 1 {
+		workers = 1
+	}
+	records := atomic.LoadInt64(&w.records)
+	start := time.Now()
+	loaded := int64(0)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			g := w.generator(seed + int64(worker))
+			for key := int64(worker); key < records; key += int64(workers) {
+				if err := setKey(client, workloadKey(key), g.value()); err != nil {
+					log.Fatalf("load phase failed at key %d: %s", key, err)
+				}
+				if n := atomic.AddInt64(&loaded, 1); n%(records/10+1) == 0 {
+					log.Printf("loaded %d/%d keys", n, records)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	log.Printf("load phase: %d keys in %s", records, time.Since(start))
+}
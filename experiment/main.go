@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	exps          = "1,2,3"
+	binDir        = "."
+	workDir       = ""
+	keep          = false
+	port          = 7000
+	expTime       = 10
+	modes         = "r,rw"
+	keySize       = 128
+	datasetKeys   = 10000
+	datasetBytes  = "256M"
+	valueSizes    = "512B,4K,512K,1M,4M"
+	recoverySize  = "4G"
+	recoveryValue = 4096
+	restarts      = 3
+	clientCounts  = "1,2,4,8,16,32,64"
+	minGain       = 0.05
+	summaryFile   = ""
+)
+
+func main() {
+	flag.StringVar(&exps, "exp", exps, "comma separated experiments of the README to run, `1` value sizes, `2` restart time, `3` client scaling")
+	flag.StringVar(&binDir, "bin_dir", binDir, "repository root holding server/kvserver, client/kvclient and kvctl/kvctl, see make build")
+	flag.StringVar(&workDir, "work_dir", workDir, "directory for server data, datasets and results, a temporary one if empty")
+	flag.BoolVar(&keep, "keep", keep, "keep the temporary -work_dir")
+	flag.IntVar(&port, "p", port, "port of the servers started")
+	flag.IntVar(&expTime, "exp_time", expTime, "seconds of every benchmark run")
+	flag.StringVar(&modes, "modes", modes, "comma separated workloads of experiments 1 and 3, `r` read only, `rw` 50% reads 50% writes")
+	flag.IntVar(&keySize, "key_size", keySize, "key size of the datasets")
+	flag.IntVar(&datasetKeys, "keys", datasetKeys, "keys of the datasets of experiments 1 and 3")
+	flag.StringVar(&datasetBytes, "dataset_size", datasetBytes, "fewer keys in experiment 1 when they would hold more than this")
+	flag.StringVar(&valueSizes, "value_sizes", valueSizes, "comma separated value sizes of experiment 1")
+	flag.StringVar(&recoverySize, "recovery_size", recoverySize, "data the server recovers in experiment 2")
+	flag.IntVar(&recoveryValue, "recovery_value_size", recoveryValue, "value size of the data of experiment 2")
+	flag.IntVar(&restarts, "restarts", restarts, "times the server is killed and restarted in experiment 2")
+	flag.StringVar(&clientCounts, "clients", clientCounts, "comma separated client counts of experiment 3")
+	flag.Float64Var(&minGain, "min_gain", minGain, "experiment 3 stops adding clients once throughput grows less than this fraction")
+	flag.StringVar(&summaryFile, "summary_file", summaryFile, "also write the summary table to this CSV file")
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Printf("%s", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	bins, err := findBinaries(binDir)
+	if err != nil {
+		return fmt.Errorf("%s, run make build first", err)
+	}
+	if workDir == "" {
+		if workDir, err = ioutil.TempDir("", "kvexp"); err != nil {
+			return err
+		}
+		if !keep {
+			defer os.RemoveAll(workDir)
+		}
+	} else if err := os.MkdirAll(workDir, 0755); err != nil {
+		return err
+	}
+	if workDir, err = filepath.Abs(workDir); err != nil {
+		return err
+	}
+	log.Printf("working in %s", workDir)
+
+	h := &harness{bins: bins, dir: workDir, port: port, duration: time.Duration(expTime) * time.Second}
+	var rows []row
+	for _, exp := range strings.Split(exps, ",") {
+		var expRows []row
+		switch strings.TrimSpace(exp) {
+		case "1":
+			expRows, err = h.exp1()
+		case "2":
+			expRows, err = h.exp2()
+		case "3":
+			expRows, err = h.exp3()
+		default:
+			err = fmt.Errorf("unknown experiment %q, want 1, 2 or 3", exp)
+		}
+		rows = append(rows, expRows...)
+		if err != nil {
+			err = fmt.Errorf("experiment %s failed: %s", exp, err)
+			break
+		}
+	}
+	// print what ran even if an experiment failed
+	printSummary(os.Stdout, rows)
+	if summaryFile != "" {
+		if err := writeSummary(summaryFile, rows); err != nil {
+			return fmt.Errorf("failed to write %s: %s", summaryFile, err)
+		}
+		log.Printf("summary written to %s", summaryFile)
+	}
+	return err
+}
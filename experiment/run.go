@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
+)
+
+// exp3ValueSize is the value size of the client scaling datasets
+const exp3ValueSize = 512
+
+// serveTimeout bounds the wait for a server to answer, recovering 4GB included
+const serveTimeout = 10 * time.Minute
+
+type binaries struct {
+	server string
+	client string
+	kvctl  string
+}
+
+func findBinaries(dir string) (binaries, error) {
+	b := binaries{
+		server: filepath.Join(dir, "server", "kvserver"),
+		client: filepath.Join(dir, "client", "kvclient"),
+		kvctl:  filepath.Join(dir, "kvctl", "kvctl"),
+	}
+	for _, path := range []*string{&b.server, &b.client, &b.kvctl} {
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return b, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return b, fmt.Errorf("missing %s", abs)
+		}
+		*path = abs
+	}
+	return b, nil
+}
+
+// harness runs the servers and clients of the experiments as subprocesses,
+// every server in a data directory of its own under dir
+type harness struct {
+	bins     binaries
+	dir      string
+	port     int
+	duration time.Duration
+}
+
+func (h *harness) addr() string {
+	return "localhost:" + strconv.Itoa(h.port)
+}
+
+// command runs a binary in dir with its output appended to logName in dir
+func (h *harness) command(dir string, logName string, bin string, args ...string) (*exec.Cmd, error) {
+	out, err := os.OpenFile(filepath.Join(dir, logName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd, nil
+}
+
+// run runs a binary to completion
+func (h *harness) run(dir string, logName string, bin string, args ...string) error {
+	cmd, err := h.command(dir, logName, bin, args...)
+	if err != nil {
+		return err
+	}
+	defer cmd.Stdout.(*os.File).Close()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %v, see %s", filepath.Base(bin), strings.Join(args, " "), err, filepath.Join(dir, logName))
+	}
+	return nil
+}
+
+type server struct {
+	cmd    *exec.Cmd
+	dir    string
+	start  time.Time
+	exited chan struct{} // closed once the process is gone
+	err    error
+}
+
+// startServer starts a server in dir, which recovers the history.log there,
+// and waits until it answers reads
+func (h *harness) startServer(dir string) (*server, time.Duration, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, 0, err
+	}
+	cmd, err := h.command(dir, "server.log", h.bins.server, "-p", strconv.Itoa(h.port))
+	if err != nil {
+		return nil, 0, err
+	}
+	s := &server{cmd: cmd, dir: dir, start: time.Now(), exited: make(chan struct{})}
+	if err := cmd.Start(); err != nil {
+		cmd.Stdout.(*os.File).Close()
+		return nil, 0, err
+	}
+	go func() {
+		s.err = cmd.Wait()
+		cmd.Stdout.(*os.File).Close()
+		close(s.exited)
+	}()
+	deadline := time.Now().Add(serveTimeout)
+	for {
+		if h.serving() {
+			return s, time.Since(s.start), nil
+		}
+		select {
+		case <-s.exited:
+			return nil, 0, fmt.Errorf("server exited: %v, see %s", s.err, filepath.Join(dir, "server.log"))
+		default:
+		}
+		if time.Now().After(deadline) {
+			s.kill()
+			return nil, 0, fmt.Errorf("server not serving after %s", serveTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// serving reports whether the server answers a read, a missing key is an answer
+func (h *harness) serving() bool {
+	c, err := kvclient.New(h.addr())
+	if err != nil {
+		return false
+	}
+	defer c.Close()
+	_, err = c.Get(context.Background(), "kvexp-probe", kvclient.Timeout(time.Second), kvclient.MaxAttempts(1))
+	return err == nil || kvclient.IsNotFound(err)
+}
+
+// stop asks the server to exit and kills it if it does not
+func (s *server) stop() {
+	s.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-s.exited:
+	case <-time.After(10 * time.Second):
+		s.kill()
+	}
+}
+
+// kill crashes the server
+func (s *server) kill() {
+	s.cmd.Process.Kill()
+	<-s.exited
+}
+
+// recoveryTime asks the running server how long loading its data took
+func (h *harness) recoveryTime() (time.Duration, error) {
+	c, err := kvclient.New(h.addr())
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	stats, err := c.Stats(context.Background(), kvclient.Timeout(10*time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(stats.GetRecoveryMs()) * time.Millisecond, nil
+}
+
+// opSummary is the part of the benchmark report of the client the summary uses
+type opSummary struct {
+	Operation  string  `json:"operation"`
+	Count      int64   `json:"count"`
+	Errors     int64   `json:"errors"`
+	Throughput float64 `json:"throughput"`
+	MeanUs     float64 `json:"mean_us"`
+	P50Us      float64 `json:"p50_us"`
+	P99Us      float64 `json:"p99_us"`
+}
+
+// benchmark runs a client benchmark of the dataset with workers goroutines
+// and connections and returns the totals of all operations
+func (h *harness) benchmark(dir string, name string, dataset string, mode string, workers int) (opSummary, error) {
+	result := filepath.Join(dir, name+".json")
+	log.Printf("running %s", name)
+	err := h.run(dir, name+".log", h.bins.client, "-p", strconv.Itoa(h.port), "-mode", "benchmark",
+		"-dataset", dataset, "-modeRW", mode, "-exp_time", strconv.Itoa(int(h.duration.Seconds())),
+		"-workers", strconv.Itoa(workers), "-conns", strconv.Itoa(workers), "-report_interval", "0", "-result_file", result)
+	if err != nil {
+		return opSummary{}, err
+	}
+	b, err := ioutil.ReadFile(result)
+	if err != nil {
+		return opSummary{}, err
+	}
+	var report struct {
+		Operations []opSummary `json:"operations"`
+	}
+	if err := json.Unmarshal(b, &report); err != nil {
+		return opSummary{}, fmt.Errorf("%s: %v", result, err)
+	}
+	for _, s := range report.Operations {
+		if s.Operation == "all" {
+			return s, nil
+		}
+	}
+	return opSummary{}, fmt.Errorf("%s: no totals", result)
+}
+
+// dataset generates a benchmark dataset named like the ones of the README
+func (h *harness) dataset(dir string, keys int64, valueSize int64) (string, error) {
+	path := filepath.Join(dir, fmt.Sprintf("KV_%d_%dB_%dB.txt", keys, keySize, valueSize))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return path, h.run(dir, "gen.log", h.bins.kvctl, "gen", "-quiet", "-count", strconv.FormatInt(keys, 10),
+		"-key_size", strconv.Itoa(keySize), "-value_size", strconv.FormatInt(valueSize, 10), "-file", path)
+}
+
+// preload starts a server in a new dir holding the keys of the dataset. The
+// datasets are in the history.log format, so the server recovers them.
+func (h *harness) preload(dir string, dataset string) (*server, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := copyFile(dataset, filepath.Join(dir, "history.log")); err != nil {
+		return nil, err
+	}
+	s, _, err := h.startServer(dir)
+	return s, err
+}
+
+func copyFile(from string, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// exp1 measures the latency of both workloads for every value size
+func (h *harness) exp1() ([]row, error) {
+	dir := filepath.Join(h.dir, "exp1")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	maxBytes, err := parseSize(datasetBytes)
+	if err != nil {
+		return nil, err
+	}
+	var rows []row
+	for _, size := range splitList(valueSizes) {
+		valueSize, err := parseSize(size)
+		if err != nil || valueSize <= 0 {
+			return rows, fmt.Errorf("invalid value size %q", size)
+		}
+		keys := int64(datasetKeys)
+		if keys*valueSize > maxBytes {
+			keys = maxBytes / valueSize
+		}
+		if keys < 1 {
+			keys = 1
+		}
+		dataset, err := h.dataset(dir, keys, valueSize)
+		if err != nil {
+			return rows, err
+		}
+		s, err := h.preload(filepath.Join(dir, "server_"+size), dataset)
+		if err != nil {
+			return rows, err
+		}
+		for _, mode := range splitList(modes) {
+			total, err := h.benchmark(dir, fmt.Sprintf("exp1_%s_%s", size, mode), dataset, mode, 1)
+			if err != nil {
+				s.stop()
+				return rows, err
+			}
+			rows = append(rows, benchmarkRow("1", fmt.Sprintf("value %s, %d keys", size, keys), mode, 1, total))
+		}
+		s.stop()
+	}
+	return rows, nil
+}
+
+// exp2 crashes the server holding -recovery_size of data and measures the
+// time until it serves reads again
+func (h *harness) exp2() ([]row, error) {
+	dir := filepath.Join(h.dir, "exp2")
+	if _, err := os.Stat(filepath.Join(dir, "history.log")); err != nil {
+		log.Printf("generating %s of data", recoverySize)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		err := h.run(dir, "gen.log", h.bins.kvctl, "gen", "-quiet", "-data_dir", ".", "-count", "0",
+			"-total_size", recoverySize, "-key_size", strconv.Itoa(keySize), "-value_size", strconv.Itoa(recoveryValue))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rows []row
+	for i := 1; i <= restarts; i++ {
+		s, serving, err := h.startServer(dir)
+		if err != nil {
+			return rows, err
+		}
+		recovered, err := h.recoveryTime()
+		if err != nil {
+			s.kill()
+			return rows, err
+		}
+		log.Printf("restart %d: serving after %s, data loaded in %s", i, serving, recovered)
+		rows = append(rows, row{exp: "2", setting: fmt.Sprintf("%s, restart %d", recoverySize, i), serveS: serving.Seconds(), recoverS: recovered.Seconds()})
+		s.kill()
+	}
+	return rows, nil
+}
+
+// exp3 adds clients until the throughput stops growing
+func (h *harness) exp3() ([]row, error) {
+	dir := filepath.Join(h.dir, "exp3")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	dataset, err := h.dataset(dir, int64(datasetKeys), exp3ValueSize)
+	if err != nil {
+		return nil, err
+	}
+	s, err := h.preload(filepath.Join(dir, "server"), dataset)
+	if err != nil {
+		return nil, err
+	}
+	defer s.stop()
+	var rows []row
+	for _, mode := range splitList(modes) {
+		best := 0.0
+		for _, count := range splitList(clientCounts) {
+			clients, err := strconv.Atoi(count)
+			if err != nil || clients < 1 {
+				return rows, fmt.Errorf("invalid client count %q", count)
+			}
+			total, err := h.benchmark(dir, fmt.Sprintf("exp3_%s_%d", mode, clients), dataset, mode, clients)
+			if err != nil {
+				return rows, err
+			}
+			rows = append(rows, benchmarkRow("3", fmt.Sprintf("%d clients", clients), mode, clients, total))
+			if best > 0 && total.Throughput < best*(1+minGain) {
+				log.Printf("%s: throughput stopped growing at %d clients", mode, clients)
+				break
+			}
+			if total.Throughput > best {
+				best = total.Throughput
+			}
+		}
+	}
+	return rows, nil
+}
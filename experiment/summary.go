@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// row is one line of the summary, a benchmark run or a restart
+type row struct {
+	exp      string
+	setting  string
+	mode     string
+	clients  int
+	total    opSummary
+	serveS   float64 // restart until reads are served
+	recoverS float64 // loading the data, as reported by the Stats of the server
+}
+
+func benchmarkRow(exp string, setting string, mode string, clients int, total opSummary) row {
+	return row{exp: exp, setting: setting, mode: mode, clients: clients, total: total}
+}
+
+var summaryHeader = []string{"exp", "setting", "mode", "clients", "ops", "errors", "ops_per_sec", "mean_us", "p50_us", "p99_us", "serve_s", "recover_s"}
+
+func (r row) fields() []string {
+	f := func(v float64, prec int) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', prec, 64)
+	}
+	fields := []string{r.exp, r.setting, r.mode, "", "", "", "", "", "", "", f(r.serveS, 3), f(r.recoverS, 3)}
+	if r.clients > 0 {
+		t := r.total
+		copy(fields[3:], []string{strconv.Itoa(r.clients), strconv.FormatInt(t.Count, 10), strconv.FormatInt(t.Errors, 10),
+			f(t.Throughput, 0), f(t.MeanUs, 0), f(t.P50Us, 0), f(t.P99Us, 0)})
+	}
+	return fields
+}
+
+func printSummary(w io.Writer, rows []row) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(summaryHeader, "\t")))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r.fields(), "\t"))
+	}
+	tw.Flush()
+}
+
+func writeSummary(filename string, rows []row) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	w.Write(summaryHeader)
+	for _, r := range rows {
+		w.Write(r.fields())
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// parseSize reads a byte count with an optional K, M, G or T suffix, like kvctl gen
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+	unit := int64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		case 'T':
+			unit = 1 << 40
+		}
+		if unit > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
@@ -0,0 +1,211 @@
+package kvclient
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+)
+
+// DefaultMaxStaleness bounds how old a cached value may be, see WithCache.
+const DefaultMaxStaleness = 5 * time.Second
+
+// entryOverhead is roughly what the list element and map slot of an entry cost
+const entryOverhead = 64
+
+// CacheStats counts the work of the near-cache since the client was created.
+type CacheStats struct {
+	Hits          int64
+	Misses        int64
+	Evictions     int64 // entries dropped to stay within the byte bound
+	Invalidations int64 // entries dropped because the server changed the key
+	Entries       int64
+	Bytes         int64
+}
+
+type cachedValue struct {
+	key       string
+	value     string
+	fetchedAt time.Time
+	gen       int64 // watch stream the entry is covered by
+}
+
+func (v *cachedValue) size() int64 {
+	return int64(len(v.key) + len(v.value) + entryOverhead)
+}
+
+// nearCache is an LRU of values read with Get, bounded by bytes. A Watch
+// stream drops the keys changed on the server. A value is served while it is
+// provably younger than maxStaleness: either it was fetched recently, or, with
+// a single endpoint, the stream it is covered by was heard from recently,
+// since any change of the key would have arrived before that message. The
+// stream of one of several endpoints misses the writes the others have not
+// passed on yet, so it only drops keys.
+type nearCache struct {
+	maxBytes     int64
+	maxStaleness time.Duration
+	trustStream  bool // the watched server is the only endpoint
+
+	lock      sync.Mutex
+	lru       *list.List // front is the most recently used
+	items     map[string]*list.Element
+	gen       int64     // bumped whenever the watch stream restarts
+	live      bool      // the server acknowledged the current watch stream
+	lastHeard time.Time // last message of the current watch stream
+	seq       int64     // bumped on every invalidation
+	stats     CacheStats
+
+	done chan struct{}
+}
+
+func newNearCache(maxBytes int64, maxStaleness time.Duration, endpoints int) *nearCache {
+	if maxStaleness <= 0 {
+		maxStaleness = DefaultMaxStaleness
+	}
+	return &nearCache{maxBytes: maxBytes, maxStaleness: maxStaleness, trustStream: endpoints == 1, lru: list.New(),
+		items: make(map[string]*list.Element), done: make(chan struct{})}
+}
+
+// get returns the cached value of the key if it is fresh enough
+func (c *nearCache) get(key string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.items[key]; ok {
+		v := elem.Value.(*cachedValue)
+		freshAsOf := v.fetchedAt
+		if c.trustStream && v.gen == c.gen && c.lastHeard.After(freshAsOf) {
+			freshAsOf = c.lastHeard
+		}
+		if time.Since(freshAsOf) <= c.maxStaleness {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			return v.value, true
+		}
+		c.removeElement(elem)
+	}
+	c.stats.Misses++
+	return "", false
+}
+
+// begin is called before fetching a key, the returned token is passed to put
+func (c *nearCache) begin() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.seq
+}
+
+// put caches a value fetched from the server, unless a key was invalidated
+// while it was in flight: the value may then predate the change.
+func (c *nearCache) put(token int64, key string, value string, fetchedAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if token != c.seq {
+		return
+	}
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+	v := &cachedValue{key: key, value: value, fetchedAt: fetchedAt}
+	if c.live {
+		v.gen = c.gen
+	}
+	if v.size() > c.maxBytes {
+		return
+	}
+	c.items[key] = c.lru.PushFront(v)
+	c.stats.Entries++
+	c.stats.Bytes += v.size()
+	for c.stats.Bytes > c.maxBytes {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *nearCache) invalidate(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.seq++
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+		c.stats.Invalidations++
+	}
+}
+
+func (c *nearCache) removeElement(elem *list.Element) {
+	v := c.lru.Remove(elem).(*cachedValue)
+	delete(c.items, v.key)
+	c.stats.Entries--
+	c.stats.Bytes -= v.size()
+}
+
+func (c *nearCache) heard(gen int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if gen == c.gen {
+		c.live = true
+		c.lastHeard = time.Now()
+	}
+}
+
+// restart starts a new generation, entries of the old one may have missed
+// changes and only live on their own fetch time
+func (c *nearCache) restart() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.gen++
+	c.seq++
+	c.live = false
+	return c.gen
+}
+
+func (c *nearCache) snapshot() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.stats
+}
+
+// watch keeps a Watch stream open against one of the endpoints and drops the
+// changed keys, reconnecting with backoff until the client is closed
+func (c *nearCache) watch(p *pool, namespace string, backoff func(retry int) time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for retry := 1; ; retry++ {
+		gen := c.restart()
+		cn := p.pick()
+		stream, err := cn.kv.Watch(ctx, &pb.WatchRequest{Namespace: namespace})
+		for err == nil {
+			var event *pb.WatchEvent
+			if event, err = stream.Recv(); err == nil {
+				if e := event.GetEntry(); e != nil {
+					c.invalidate(e.GetKey())
+				}
+				c.heard(gen)
+				retry = 0
+			}
+		}
+		p.release(cn, err)
+		wait := backoff(retry)
+		if after, ok := retryAfter(err); ok && after > wait {
+			wait = after
+		}
+		select {
+		case <-time.After(wait):
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *nearCache) close() {
+	close(c.done)
+}
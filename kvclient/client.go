@@ -0,0 +1,416 @@
+// Package kvclient is the Go client of the kvserver.
+//
+//	c, err := kvclient.New("localhost:6000")
+//	if err != nil { ... }
+//	defer c.Close()
+//	err = c.Set(ctx, "key", "value")
+//	value, err := c.Get(ctx, "key", kvclient.Timeout(time.Second))
+//	if kvclient.IsNotFound(err) { ... }
+//
+// Every operation of the store is idempotent (a Set stores the same value
+// again), so calls failing with Unavailable are retried with exponential
+// backoff and jitter, see RetryPolicy. Calls rejected by an overloaded or
+// rate limiting server are retried too, no sooner than the server asked.
+package kvclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Client talks to one or more kvservers, it is safe for concurrent use.
+type Client struct {
+	pool  *pool
+	cache *nearCache // nil unless WithCache is used
+	opts  options
+
+	lock   sync.Mutex
+	closed bool
+}
+
+// New dials the server at addr, and the ones added with WithEndpoints. An
+// empty addr is skipped. Connections are set up in the background, so New
+// does not fail when a server is down.
+func New(addr string, opts ...Option) (*Client, error) {
+	o := options{connsPerEndpoint: 1, healthInterval: DefaultHealthCheckInterval, retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	endpoints := []string{}
+	seen := make(map[string]bool)
+	for _, ep := range append([]string{addr}, o.endpoints...) {
+		if ep != "" && !seen[ep] {
+			seen[ep] = true
+			endpoints = append(endpoints, ep)
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("kvclient: no endpoint given")
+	}
+	transport := grpc.WithInsecure()
+	if o.tls != nil {
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(o.tls))
+	}
+	dialOptions := []grpc.DialOption{transport,
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor, retryAfterUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(tracing.StreamClientInterceptor, retryAfterStreamInterceptor)}
+	if o.token != "" {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(tokenCredentials{token: o.token, secure: o.tls != nil}))
+	}
+	dialOptions = append(dialOptions, o.dialOptions...)
+	p, err := newPool(endpoints, o.connsPerEndpoint, o.balancer, dialOptions)
+	if err != nil {
+		return nil, err
+	}
+	go p.healthLoop(o.healthInterval)
+	c := &Client{pool: p, opts: o}
+	if o.cacheBytes > 0 {
+		c.cache = newNearCache(o.cacheBytes, o.maxStaleness, len(endpoints))
+		go c.cache.watch(p, o.namespace, c.backoff)
+	}
+	return c, nil
+}
+
+// Close tears down the connections, calls after Close fail with ErrClosed.
+func (c *Client) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.cache != nil {
+		c.cache.close()
+	}
+	return c.pool.close()
+}
+
+// Endpoints returns the endpoints currently taking calls.
+func (c *Client) Endpoints() []string {
+	return c.pool.healthy()
+}
+
+// CacheStats returns the statistics of the near-cache, all zero without WithCache.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.snapshot()
+}
+
+// invalidate drops a key written by this client, whether or not the write went through
+func (c *Client) invalidate(key string) {
+	if c.cache != nil {
+		c.cache.invalidate(key)
+	}
+}
+
+func (c *Client) isClosed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.closed
+}
+
+func (c *Client) callOptions(opts []CallOption) callOptions {
+	co := callOptions{timeout: DefaultTimeout, maxAttempts: c.opts.retry.MaxAttempts}
+	for _, opt := range c.opts.callOptions {
+		opt(&co)
+	}
+	for _, opt := range opts {
+		opt(&co)
+	}
+	return co
+}
+
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Aborted:
+		return true
+	case codes.ResourceExhausted:
+		// overload and rate limits pass, quotas of keys and bytes do not
+		_, ok := retryAfter(err)
+		return ok
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry, counted from 1
+func (c *Client) backoff(retry int) time.Duration {
+	ceiling := c.opts.retry.BaseDelay << uint(retry-1)
+	if ceiling <= 0 || ceiling > c.opts.retry.MaxDelay {
+		ceiling = c.opts.retry.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// invoke runs call until it succeeds, fails with an error not worth retrying,
+// runs out of attempts or the deadline of the call passes. Every attempt picks
+// a connection, so a retry may go to another endpoint.
+func (c *Client) invoke(ctx context.Context, co callOptions, call func(ctx context.Context, cn *conn) error) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	ctx = withRequestID(ctx)
+	if co.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, co.timeout)
+		defer cancel()
+	}
+	for attempt := 1; ; attempt++ {
+		cn := c.pool.pick()
+		err := call(ctx, cn)
+		c.pool.release(cn, err)
+		if err == nil || attempt >= co.maxAttempts || !retryable(err) {
+			return err
+		}
+		wait := c.backoff(attempt)
+		if after, ok := retryAfter(err); ok && after > wait {
+			wait = after
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err // would fail with DeadlineExceeded, hiding why
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// Get returns the value of the key, or an error matching ErrNotFound. With
+// WithCache the value may come from the near-cache unless BypassCache is given.
+func (c *Client) Get(ctx context.Context, key string, opts ...CallOption) (string, error) {
+	co := c.callOptions(opts)
+	useCache := c.cache != nil && !co.bypassCache
+	if useCache {
+		if value, ok := c.cache.get(key); ok {
+			return value, nil
+		}
+	}
+	var token int64
+	if useCache {
+		token = c.cache.begin()
+	}
+	start := time.Now()
+	var value string
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.kv.Get(ctx, &pb.GetRequest{Key: key, ReadOptions: co.readOptions, Quorum: co.quorum, Namespace: c.opts.namespace})
+		value = res.GetValue()
+		return err
+	})
+	if useCache && err == nil {
+		c.cache.put(token, key, value, start)
+	}
+	return value, newError("get", key, err)
+}
+
+// Set stores the value of the key.
+func (c *Client) Set(ctx context.Context, key string, value string, opts ...CallOption) error {
+	co := c.callOptions(opts)
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		_, err := cn.kv.Set(ctx, &pb.SetRequest{Key: key, Value: value, Quorum: co.quorum, Namespace: c.opts.namespace})
+		return err
+	})
+	c.invalidate(key)
+	return newError("set", key, err)
+}
+
+// GetPrefix returns the values of every key starting with prefix, or an error
+// matching ErrNotFound if there is none.
+func (c *Client) GetPrefix(ctx context.Context, prefix string, opts ...CallOption) ([]string, error) {
+	co := c.callOptions(opts)
+	var values []string
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.kv.GetPrefix(ctx, &pb.GetPrefixRequest{Key: prefix, ReadOptions: co.readOptions, Namespace: c.opts.namespace})
+		values = res.GetValues()
+		return err
+	})
+	return values, newError("getPrefix", prefix, err)
+}
+
+// Delete removes the key, deleting a missing key is not an error.
+func (c *Client) Delete(ctx context.Context, key string, opts ...CallOption) error {
+	co := c.callOptions(opts)
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		_, err := cn.kv.Delete(ctx, &pb.DeleteRequest{Key: key, Quorum: co.quorum, Namespace: c.opts.namespace})
+		return err
+	})
+	c.invalidate(key)
+	return newError("delete", key, err)
+}
+
+// Members returns the servers of the cluster as seen by the server.
+func (c *Client) Members(ctx context.Context, opts ...CallOption) ([]*pb.Member, error) {
+	co := c.callOptions(opts)
+	var members []*pb.Member
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.admin.Members(ctx, &pb.MembersRequest{})
+		members = res.GetMembers()
+		return err
+	})
+	return members, newError("members", "", err)
+}
+
+// Stats returns the uptime, op totals and storage figures of the server.
+func (c *Client) Stats(ctx context.Context, opts ...CallOption) (*pb.StatsResponse, error) {
+	co := c.callOptions(opts)
+	var stats *pb.StatsResponse
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.admin.Stats(ctx, &pb.StatsRequest{})
+		stats = res
+		return err
+	})
+	return stats, newError("stats", "", err)
+}
+
+// SetLogLevel changes the level of the server log to debug, info, warn or
+// error until the server restarts, and returns the previous and current
+// levels. An empty level only reads the current one.
+func (c *Client) SetLogLevel(ctx context.Context, level string, opts ...CallOption) (previous string, current string, err error) {
+	co := c.callOptions(opts)
+	err = c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.admin.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: level})
+		previous, current = res.GetPrevious(), res.GetLevel()
+		return err
+	})
+	return previous, current, newError("setLogLevel", level, err)
+}
+
+// SetACL replaces the grants of a principal, no grants remove them.
+func (c *Client) SetACL(ctx context.Context, principal string, grants []*pb.Grant, opts ...CallOption) error {
+	co := c.callOptions(opts)
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		_, err := cn.admin.SetAcl(ctx, &pb.Acl{Principal: principal, Grants: grants})
+		return err
+	})
+	return newError("setACL", principal, err)
+}
+
+// ACLs returns the grants of one principal, or of all of them if empty.
+func (c *Client) ACLs(ctx context.Context, principal string, opts ...CallOption) ([]*pb.Acl, error) {
+	co := c.callOptions(opts)
+	var acls []*pb.Acl
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.admin.GetAcls(ctx, &pb.GetAclsRequest{Principal: principal})
+		acls = res.GetAcls()
+		return err
+	})
+	return acls, newError("acls", principal, err)
+}
+
+// SetQuota replaces the limits of the namespace of the quota, all zero
+// removes them.
+func (c *Client) SetQuota(ctx context.Context, quota *pb.Quota, opts ...CallOption) error {
+	co := c.callOptions(opts)
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		_, err := cn.admin.SetQuota(ctx, quota)
+		return err
+	})
+	return newError("setQuota", quota.GetNamespace(), err)
+}
+
+// Namespaces returns the quota and usage of the given namespaces, or of every
+// namespace the server knows if none is given.
+func (c *Client) Namespaces(ctx context.Context, namespaces []string, opts ...CallOption) ([]*pb.NamespaceStats, error) {
+	co := c.callOptions(opts)
+	var stats []*pb.NamespaceStats
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.admin.GetNamespaces(ctx, &pb.GetNamespacesRequest{Namespaces: namespaces})
+		stats = res.GetNamespaces()
+		return err
+	})
+	return stats, newError("namespaces", strings.Join(namespaces, ","), err)
+}
+
+// VerifyReplicas compares the server with the given peers, or with all its
+// peers if none is given, and reports the keys that differ.
+func (c *Client) VerifyReplicas(ctx context.Context, peers []string, opts ...CallOption) ([]*pb.PeerReport, error) {
+	co := c.callOptions(opts)
+	var reports []*pb.PeerReport
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		res, err := cn.admin.VerifyReplicas(ctx, &pb.VerifyReplicasRequest{Peers: peers})
+		reports = res.GetReports()
+		return err
+	})
+	return reports, newError("verifyReplicas", "", err)
+}
+
+// Scan calls fn with every key starting with prefix and its value, in no
+// particular order, stopping at the first error fn returns. Only a scan that
+// has not delivered anything yet is retried. Large scans may need a longer
+// Timeout than DefaultTimeout.
+func (c *Client) Scan(ctx context.Context, prefix string, fn func(key string, value string) error, opts ...CallOption) error {
+	co := c.callOptions(opts)
+	delivered := false
+	var fnErr, brokenErr error
+	err := c.invoke(ctx, co, func(ctx context.Context, cn *conn) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream, err := cn.kv.Scan(ctx, &pb.ScanRequest{Prefix: prefix, ReadOptions: co.readOptions, Namespace: c.opts.namespace})
+		if err != nil {
+			return err
+		}
+		for {
+			e, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				if delivered {
+					brokenErr = err // not retried, the scan is half done
+					return nil
+				}
+				return err
+			}
+			delivered = true
+			if fnErr = fn(e.GetKey(), e.GetValue()); fnErr != nil {
+				return nil
+			}
+		}
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if brokenErr != nil {
+		err = brokenErr
+	}
+	return newError("scan", prefix, err)
+}
+
+// Watch calls fn with every change of the keys starting with one of the
+// prefixes, or of every key without prefixes, until ctx is done, the stream
+// breaks or fn returns an error. Deleted keys come with Deleted set.
+func (c *Client) Watch(ctx context.Context, prefixes []string, fn func(e *pb.Entry) error) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	cn := c.pool.pick()
+	stream, err := cn.kv.Watch(withRequestID(ctx), &pb.WatchRequest{Prefixes: prefixes, Namespace: c.opts.namespace})
+	for err == nil {
+		var event *pb.WatchEvent
+		if event, err = stream.Recv(); err == nil && event.GetEntry() != nil {
+			if fnErr := fn(event.GetEntry()); fnErr != nil {
+				c.pool.release(cn, nil)
+				return fnErr
+			}
+		}
+	}
+	c.pool.release(cn, err)
+	return newError("watch", strings.Join(prefixes, ","), err)
+}
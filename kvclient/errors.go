@@ -0,0 +1,79 @@
+package kvclient
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors returned by the Client can be matched with errors.Is against these.
+var (
+	ErrNotFound           = errors.New("kvclient: not found")
+	ErrInvalidArgument    = errors.New("kvclient: invalid argument")
+	ErrFailedPrecondition = errors.New("kvclient: failed precondition")
+	ErrUnavailable        = errors.New("kvclient: server unavailable")
+	ErrDeadlineExceeded   = errors.New("kvclient: deadline exceeded")
+	ErrCanceled           = errors.New("kvclient: canceled")
+	ErrClosed             = errors.New("kvclient: client closed")
+	ErrUnauthenticated    = errors.New("kvclient: unauthenticated")
+	ErrPermissionDenied   = errors.New("kvclient: permission denied")
+	ErrResourceExhausted  = errors.New("kvclient: resource exhausted")
+)
+
+var codeErrors = map[codes.Code]error{
+	codes.NotFound:           ErrNotFound,
+	codes.InvalidArgument:    ErrInvalidArgument,
+	codes.FailedPrecondition: ErrFailedPrecondition,
+	codes.Unavailable:        ErrUnavailable,
+	codes.DeadlineExceeded:   ErrDeadlineExceeded,
+	codes.Canceled:           ErrCanceled,
+	codes.Unauthenticated:    ErrUnauthenticated,
+	codes.PermissionDenied:   ErrPermissionDenied,
+	codes.ResourceExhausted:  ErrResourceExhausted,
+}
+
+// Error is returned by every failed call of the Client.
+type Error struct {
+	Op   string     // the method called, e.g. get or getPrefix
+	Key  string     // the key or prefix of the call
+	Code codes.Code // gRPC status code returned by the server
+	Msg  string     // message returned by the server
+	// RetryAfter is how long the server asked to wait before calling again,
+	// set when it rejected the call for overload or a rate limit
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("kvclient: %s %s: %s", e.Op, e.Key, e.Msg)
+}
+
+// Is makes errors.Is(err, ErrNotFound) and friends work.
+func (e *Error) Is(target error) bool {
+	return codeErrors[e.Code] == target
+}
+
+func newError(op string, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == ErrClosed {
+		return err
+	}
+	st := status.Convert(err)
+	after, _ := retryAfter(err)
+	return &Error{Op: op, Key: key, Code: st.Code(), Msg: st.Message(), RetryAfter: after}
+}
+
+// IsNotFound reports whether err means the key or prefix does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsPermissionDenied reports whether err means the caller was not identified
+// or lacks a grant for the key.
+func IsPermissionDenied(err error) bool {
+	return errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrUnauthenticated)
+}
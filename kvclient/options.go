@@ -0,0 +1,178 @@
+package kvclient
+
+import (
+	"crypto/tls"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc"
+)
+
+// DefaultTimeout bounds every call, retries included, unless changed with Timeout.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultRetryPolicy retries a call three times, waiting 50ms, 100ms and 200ms
+// on average in between.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, BaseDelay: 50 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+// RetryPolicy controls how failed calls are retried. The wait before retry n
+// is picked at random between 0 and min(MaxDelay, BaseDelay * 2^n), so
+// clients failing together do not come back together.
+type RetryPolicy struct {
+	MaxAttempts int // 1 disables retries
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type options struct {
+	endpoints        []string
+	connsPerEndpoint int
+	balancer         Balancer
+	healthInterval   time.Duration
+	cacheBytes       int64
+	maxStaleness     time.Duration
+	tls              *tls.Config
+	token            string
+	namespace        string
+	dialOptions      []grpc.DialOption
+	callOptions      []CallOption
+	retry            RetryPolicy
+}
+
+// Option configures a Client.
+type Option func(*options)
+
+// WithEndpoints adds servers to spread the calls over, next to the one given to New.
+func WithEndpoints(addrs ...string) Option {
+	return func(o *options) {
+		o.endpoints = append(o.endpoints, addrs...)
+	}
+}
+
+// WithConnsPerEndpoint opens n connections to each endpoint, so a busy client
+// is not limited by a single HTTP/2 connection.
+func WithConnsPerEndpoint(n int) Option {
+	return func(o *options) {
+		o.connsPerEndpoint = n
+	}
+}
+
+// WithBalancer sets how connections are picked, RoundRobin by default.
+func WithBalancer(b Balancer) Option {
+	return func(o *options) {
+		o.balancer = b
+	}
+}
+
+// WithHealthCheckInterval changes how often endpoints are probed, 0 disables
+// health checks. Only used with more than one endpoint.
+func WithHealthCheckInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.healthInterval = d
+	}
+}
+
+// WithCache keeps up to maxBytes of values read with Get in the client. The
+// server streams the keys that change, and a cached value is never served
+// when it may be older than maxStaleness, DefaultMaxStaleness if 0. With a
+// single endpoint values stay cached as long as its stream is alive, with
+// several they expire maxStaleness after being read, since the stream of one
+// endpoint misses the writes the others have not passed on yet.
+func WithCache(maxBytes int64, maxStaleness time.Duration) Option {
+	return func(o *options) {
+		o.cacheBytes = maxBytes
+		o.maxStaleness = maxStaleness
+	}
+}
+
+// WithTLS dials the servers over TLS instead of plaintext, see package
+// tlsconfig for a configuration reloading its certificates.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tls = cfg
+	}
+}
+
+// WithToken sends the bearer token to servers checking them, see -auth_tokens
+// of kvserver.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithNamespace makes every call of the client work on the keys of the
+// namespace, and only see those. The default namespace is "".
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithDialOptions adds options used to dial the server, e.g. credentials.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// WithDefaultCallOptions sets options applied to every call before its own.
+func WithDefaultCallOptions(opts ...CallOption) Option {
+	return func(o *options) {
+		o.callOptions = append(o.callOptions, opts...)
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+type callOptions struct {
+	timeout     time.Duration
+	maxAttempts int
+	readOptions *pb.ReadOptions
+	quorum      *pb.Quorum
+	bypassCache bool
+}
+
+// CallOption configures a single call.
+type CallOption func(*callOptions)
+
+// Timeout bounds the call including its retries, 0 leaves it to the context.
+func Timeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// MaxAttempts overrides the number of attempts of the retry policy, 1 disables retries.
+func MaxAttempts(n int) CallOption {
+	return func(o *callOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// ReadOptions sets the consistency of Get and GetPrefix calls.
+func ReadOptions(r *pb.ReadOptions) CallOption {
+	return func(o *callOptions) {
+		o.readOptions = r
+	}
+}
+
+// BypassCache reads from the server even if the value is cached.
+func BypassCache() CallOption {
+	return func(o *callOptions) {
+		o.bypassCache = true
+	}
+}
+
+// Quorum stores the key on n replicas, Set and Delete wait for w of them and
+// Get for r of them. nil keeps the key on the server the client talks to.
+func Quorum(q *pb.Quorum) CallOption {
+	return func(o *callOptions) {
+		o.quorum = q
+	}
+}
@@ -0,0 +1,178 @@
+package kvclient
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Balancer picks the connection of each call.
+type Balancer int
+
+const (
+	// RoundRobin cycles through the connections of the healthy endpoints.
+	RoundRobin Balancer = iota
+	// LeastLoaded picks the connection with the fewest calls in flight.
+	LeastLoaded
+)
+
+// DefaultHealthCheckInterval is how often endpoints are probed.
+const DefaultHealthCheckInterval = 5 * time.Second
+
+// conn is one connection of the pool, the fields used atomically come first
+// for 64-bit alignment
+type conn struct {
+	inflight int64
+	endpoint *endpoint
+	cc       *grpc.ClientConn
+	kv       pb.KVStoreClient
+	admin    pb.AdminClient
+}
+
+type endpoint struct {
+	ejected int32 // 1 while the endpoint fails health checks
+	addr    string
+	conns   []*conn
+	health  healthpb.HealthClient
+}
+
+// pool spreads calls over several connections to each of several endpoints.
+// Endpoints failing calls with Unavailable or failing a health check are
+// ejected, and admitted again once a health check passes.
+type pool struct {
+	next      uint64
+	endpoints []*endpoint
+	conns     []*conn
+	balancer  Balancer
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPool(addrs []string, connsPerEndpoint int, balancer Balancer, dialOptions []grpc.DialOption) (*pool, error) {
+	if connsPerEndpoint < 1 {
+		connsPerEndpoint = 1
+	}
+	p := &pool{balancer: balancer, done: make(chan struct{})}
+	for _, addr := range addrs {
+		ep := &endpoint{addr: addr}
+		for i := 0; i < connsPerEndpoint; i++ {
+			cc, err := grpc.Dial(addr, dialOptions...)
+			if err != nil {
+				p.close()
+				return nil, err
+			}
+			c := &conn{endpoint: ep, cc: cc, kv: pb.NewKVStoreClient(cc), admin: pb.NewAdminClient(cc)}
+			ep.conns = append(ep.conns, c)
+			p.conns = append(p.conns, c)
+		}
+		ep.health = healthpb.NewHealthClient(ep.conns[0].cc)
+		p.endpoints = append(p.endpoints, ep)
+	}
+	return p, nil
+}
+
+// pick returns the connection for the next call, from the healthy endpoints
+// or from all of them if every endpoint is ejected
+func (p *pool) pick() *conn {
+	candidates := make([]*conn, 0, len(p.conns))
+	for _, c := range p.conns {
+		if atomic.LoadInt32(&c.endpoint.ejected) == 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = p.conns
+	}
+	start := int(atomic.AddUint64(&p.next, 1) % uint64(len(candidates)))
+	picked := candidates[start]
+	if p.balancer == LeastLoaded {
+		for i := 1; i < len(candidates); i++ {
+			c := candidates[(start+i)%len(candidates)]
+			if atomic.LoadInt64(&c.inflight) < atomic.LoadInt64(&picked.inflight) {
+				picked = c
+			}
+		}
+	}
+	atomic.AddInt64(&picked.inflight, 1)
+	return picked
+}
+
+// release returns the connection after a call, ejecting its endpoint if the
+// call could not reach the server
+func (p *pool) release(c *conn, err error) {
+	atomic.AddInt64(&c.inflight, -1)
+	if status.Code(err) == codes.Unavailable && len(p.endpoints) > 1 {
+		atomic.StoreInt32(&c.endpoint.ejected, 1)
+	}
+}
+
+// check probes the endpoint with the standard gRPC health service. Servers not
+// offering it count as healthy as long as they answer.
+func (ep *endpoint) check(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	res, err := ep.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return true
+	}
+	return err == nil && res.GetStatus() == healthpb.HealthCheckResponse_SERVING
+}
+
+func (p *pool) healthLoop(interval time.Duration) {
+	if len(p.endpoints) < 2 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+		var wg sync.WaitGroup
+		for _, ep := range p.endpoints {
+			wg.Add(1)
+			go func(ep *endpoint) {
+				defer wg.Done()
+				if ep.check(interval) {
+					atomic.StoreInt32(&ep.ejected, 0)
+				} else {
+					atomic.StoreInt32(&ep.ejected, 1)
+				}
+			}(ep)
+		}
+		wg.Wait()
+	}
+}
+
+// healthy returns the endpoints currently taking calls
+func (p *pool) healthy() []string {
+	addrs := []string{}
+	for _, ep := range p.endpoints {
+		if atomic.LoadInt32(&ep.ejected) == 0 {
+			addrs = append(addrs, ep.addr)
+		}
+	}
+	return addrs
+}
+
+func (p *pool) close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.done)
+		for _, c := range p.conns {
+			if cerr := c.cc.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	})
+	return err
+}
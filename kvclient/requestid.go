@@ -0,0 +1,30 @@
+package kvclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey is the metadata key of the request id the server logs every
+// call with and echoes in the response header.
+const RequestIDKey = "x-request-id"
+
+// WithRequestID returns a context making the calls of the client carry id as
+// their request id, e.g. to find a call in the server logs. Without one every
+// call gets a random id, shared by its retries.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, RequestIDKey, id)
+}
+
+// withRequestID gives the call of ctx a request id unless the caller did
+func withRequestID(ctx context.Context) context.Context {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDKey)) > 0 {
+		return ctx
+	}
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return WithRequestID(ctx, hex.EncodeToString(buf))
+}
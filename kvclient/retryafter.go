@@ -0,0 +1,79 @@
+package kvclient
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// retryAfterKey is the trailer of a call rejected by an overloaded server or
+// rate limit, holding the milliseconds to wait before trying again
+const retryAfterKey = "retry-after-ms"
+
+// retryAfterError is a ResourceExhausted error carrying the hint of the server
+type retryAfterError struct {
+	error
+	after time.Duration
+}
+
+func (e *retryAfterError) GRPCStatus() *status.Status {
+	return status.Convert(e.error)
+}
+
+// withRetryAfter attaches the hint of the trailer, if any, to err
+func withRetryAfter(err error, trailer metadata.MD) error {
+	if status.Code(err) != codes.ResourceExhausted {
+		return err
+	}
+	values := trailer.Get(retryAfterKey)
+	if len(values) == 0 {
+		return err
+	}
+	ms, perr := strconv.ParseInt(values[0], 10, 64)
+	if perr != nil || ms < 0 {
+		return err
+	}
+	return &retryAfterError{error: err, after: time.Duration(ms) * time.Millisecond}
+}
+
+// retryAfter returns the wait the server asked for, ok is false without hint
+func retryAfter(err error) (time.Duration, bool) {
+	var e *retryAfterError
+	if errors.As(err, &e) {
+		return e.after, true
+	}
+	return 0, false
+}
+
+func retryAfterUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var trailer metadata.MD
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Trailer(&trailer))...)
+	return withRetryAfter(err, trailer)
+}
+
+// retryAfterStream attaches the hint to the error ending the stream
+type retryAfterStream struct {
+	grpc.ClientStream
+}
+
+func (s retryAfterStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		err = withRetryAfter(err, s.Trailer())
+	}
+	return err
+}
+
+func retryAfterStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return retryAfterStream{cs}, nil
+}
@@ -0,0 +1,18 @@
+package kvclient
+
+import "context"
+
+// tokenCredentials sends a bearer token with every call
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity keeps a token meant for TLS off plaintext connections
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
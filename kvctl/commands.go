@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/bulk"
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+)
+
+// command is a subcommand of kvctl. setup registers its flags and returns the
+// function running it on the remaining arguments.
+type command struct {
+	name    string
+	args    string
+	summary string
+	setup   func(fs *flag.FlagSet) func(args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"get", "KEY...", "print the value of keys", setupGet},
+		{"set", "KEY [VALUE|-]", "store a value, read from stdin if missing or -", setupSet},
+		{"del", "KEY...", "delete keys", setupDel},
+		{"scan", "[PREFIX]", "print the keys starting with prefix and their values", setupScan},
+		{"watch", "[PREFIX...]", "print changes of keys as they happen", setupWatch},
+		{"stats", "", "print the figures of the server and the state of the cluster", setupStats},
+		{"import", "[FILE|-]", "store key/value pairs read from a file, resumable", setupImport},
+		{"export", "[PREFIX]", "write the keys starting with prefix to a file", setupExport},
+		{"gen", "", "write a synthetic dataset, without a server", setupGen},
+		{"acl", "[get [PRINCIPAL] | set PRINCIPAL PERMISSION[@NAMESPACE]:PREFIX... | del PRINCIPAL]", "show or change what principals may do", setupACL},
+		{"ns", "[get [NAMESPACE...] | set NAMESPACE | del NAMESPACE]", "show the usage of namespaces or change their quota", setupNamespace},
+		{"loglevel", "[debug|info|warn|error]", "show or change the level of the server log", setupLogLevel},
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// run runs the command named by args[0] with the rest of args
+func run(args []string) error {
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		return usageError("unknown command %q, see kvctl -h", args[0])
+	}
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: kvctl " + cmd.name + " [flags] " + cmd.args + "\n"))
+		fs.PrintDefaults()
+	}
+	globalFlags(fs)
+	runner := cmd.setup(fs)
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return &codeError{code: exitUsage, err: err}
+	}
+	switch output {
+	case "json", "table", "raw":
+	default:
+		return usageError("invalid output %q, want json, table or raw", output)
+	}
+	return runner(positional)
+}
+
+// parseInterspersed parses flags given before, between or after the
+// arguments, everything after -- is an argument
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func setupGet(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) == 0 {
+			return usageError("get needs a key")
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		p := newPrinter("KEY", "VALUE")
+		defer p.flush()
+		var firstErr error
+		for _, key := range args {
+			value, err := c.Get(context.Background(), key, kvclient.Timeout(timeout))
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			p.pair(key, value)
+		}
+		return firstErr
+	}
+}
+
+func setupSet(fs *flag.FlagSet) func(args []string) error {
+	file := fs.String("file", "", "read the value from `path`, stored as is, newlines included")
+	return func(args []string) error {
+		if len(args) == 0 || len(args) > 2 {
+			return usageError("set needs a key and at most one value")
+		}
+		var value string
+		switch {
+		case *file != "" && len(args) == 2:
+			return usageError("set takes a value or -file, not both")
+		case *file != "":
+			b, err := ioutil.ReadFile(*file)
+			if err != nil {
+				return err
+			}
+			value = string(b)
+		case len(args) == 1 || args[1] == "-":
+			b, err := ioutil.ReadAll(stdin)
+			if err != nil {
+				return err
+			}
+			value = string(b)
+		default:
+			value = args[1]
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		if err := c.Set(context.Background(), args[0], value, kvclient.Timeout(timeout)); err != nil {
+			return err
+		}
+		newPrinter().done("OK")
+		return nil
+	}
+}
+
+func setupDel(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) == 0 {
+			return usageError("del needs a key")
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		for _, key := range args {
+			if err := c.Delete(context.Background(), key, kvclient.Timeout(timeout)); err != nil {
+				return err
+			}
+		}
+		newPrinter().done("OK")
+		return nil
+	}
+}
+
+func setupScan(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 1 {
+			return usageError("scan takes at most one prefix")
+		}
+		prefix := ""
+		if len(args) == 1 {
+			prefix = args[0]
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		p := newPrinter("KEY", "VALUE")
+		defer p.flush()
+		return c.Scan(context.Background(), prefix, func(key string, value string) error {
+			p.pair(key, value)
+			return nil
+		}, kvclient.Timeout(0))
+	}
+}
+
+func setupWatch(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		// stop cleanly on ctrl-c, which also leaves the shell running
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+		go func() {
+			select {
+			case <-interrupt:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		p := newPrinter("EVENT", "KEY", "VALUE")
+		err = c.Watch(ctx, args, func(e *pb.Entry) error {
+			p.event(e)
+			p.flush()
+			return nil
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+}
+
+func setupStats(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("stats takes no argument")
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		stats, err := c.Stats(context.Background(), kvclient.Timeout(timeout))
+		if err != nil {
+			return err
+		}
+		members, err := c.Members(context.Background(), kvclient.Timeout(timeout))
+		if err != nil {
+			return err
+		}
+		sp := newPrinter("STAT", "VALUE")
+		sp.stats(stats)
+		sp.flush()
+		if output == "table" {
+			fmt.Fprintln(stdout)
+		}
+		p := newPrinter("MEMBER", "STATE", "LAST SEEN")
+		defer p.flush()
+		for _, m := range members {
+			p.member(m)
+		}
+		return nil
+	}
+}
+
+// formatFlag adds the -format flag of import and export
+func formatFlag(fs *flag.FlagSet, def string) *string {
+	return fs.String("format", def, "file format, `"+strings.Join(bulk.Formats, "`, `")+"`, guessed from the file name if empty")
+}
+
+func setupImport(fs *flag.FlagSet) func(args []string) error {
+	format := formatFlag(fs, "")
+	batch := fs.Int("batch", bulk.DefaultBatchSize, "number of set calls kept in flight")
+	checkpoint := fs.String("checkpoint", "", "file recording progress to resume an interrupted import, FILE.checkpoint by default, none for stdin")
+	quiet := fs.Bool("quiet", false, "do not report progress on stderr")
+	return func(args []string) error {
+		if len(args) > 1 {
+			return usageError("import takes at most one file")
+		}
+		in := stdin
+		source := "-"
+		if len(args) == 1 && args[0] != "-" {
+			source = args[0]
+			f, err := os.Open(source)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+			if *checkpoint == "" {
+				*checkpoint = source + ".checkpoint"
+			}
+			if *format == "" {
+				*format = bulk.FormatOf(source)
+			}
+		}
+		if *format == "" {
+			*format = "jsonl"
+		}
+		r, err := bulk.NewReader(in, *format)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		var last time.Time
+		opts := bulk.ImportOptions{BatchSize: *batch, Checkpoint: *checkpoint, Source: source,
+			CallOptions: []kvclient.CallOption{kvclient.Timeout(timeout)},
+			Progress: func(p bulk.Progress) {
+				if *quiet || time.Since(last) < time.Second {
+					return
+				}
+				last = time.Now()
+				rate := float64(p.Records-p.Skipped) / p.Elapsed.Seconds()
+				fmt.Fprintf(os.Stderr, "imported %d records (%d resumed), %.0f records/s, %.1f MB\n",
+					p.Records, p.Skipped, rate, float64(p.Bytes)/(1024*1024))
+			}}
+		count, err := bulk.Import(context.Background(), c, r, opts)
+		if err != nil {
+			if *checkpoint != "" {
+				return fmt.Errorf("%v, run the same import again to resume from %s", err, *checkpoint)
+			}
+			return err
+		}
+		newPrinter().done(fmt.Sprintf("imported %d records", count))
+		return nil
+	}
+}
+
+func setupExport(fs *flag.FlagSet) func(args []string) error {
+	format := formatFlag(fs, "")
+	file := fs.String("file", "", "write to `path` instead of stdout")
+	return func(args []string) error {
+		if len(args) > 1 {
+			return usageError("export takes at most one prefix")
+		}
+		prefix := ""
+		if len(args) == 1 {
+			prefix = args[0]
+		}
+		if *format == "" {
+			*format = "jsonl"
+			if *file != "" {
+				*format = bulk.FormatOf(*file)
+			}
+		}
+		out := stdout
+		if *file != "" {
+			// write next to the target and rename, a failed export leaves no half file
+			f, err := ioutil.TempFile(filepath.Dir(*file), filepath.Base(*file)+".tmp")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			out = f
+		}
+		w, err := bulk.NewWriter(out, *format)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		count, err := bulk.Export(context.Background(), c, prefix, w)
+		if err != nil {
+			return err
+		}
+		if f, ok := out.(*os.File); ok && *file != "" {
+			if err := f.Close(); err != nil {
+				return err
+			}
+			if err := os.Rename(f.Name(), *file); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "exported %d records to %s\n", count, *file)
+		}
+		return nil
+	}
+}
+
+// parseGrant reads permission[@namespace]:prefix, e.g. read:user: or
+// write@team:
+func parseGrant(arg string) (*pb.Grant, error) {
+	i := strings.IndexByte(arg, ':')
+	if i < 0 {
+		return nil, usageError("invalid grant %q, want permission[@namespace]:prefix", arg)
+	}
+	name, ns := arg[:i], ""
+	if j := strings.IndexByte(name, '@'); j >= 0 {
+		name, ns = name[:j], name[j+1:]
+	}
+	perm, ok := pb.Permission_value[strings.ToUpper(name)]
+	if !ok || perm == int32(pb.Permission_NONE) {
+		return nil, usageError("unknown permission %q, want read, write or admin", name)
+	}
+	return &pb.Grant{Permission: pb.Permission(perm), Namespace: ns, Prefix: arg[i+1:]}, nil
+}
+
+func setupACL(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		action := "get"
+		if len(args) > 0 {
+			action, args = args[0], args[1:]
+		}
+		var grants []*pb.Grant
+		switch action {
+		case "get":
+			if len(args) > 1 {
+				return usageError("acl get takes at most one principal")
+			}
+		case "set":
+			if len(args) < 2 {
+				return usageError("acl set needs a principal and grants, use acl del to remove them all")
+			}
+			for _, arg := range args[1:] {
+				g, err := parseGrant(arg)
+				if err != nil {
+					return err
+				}
+				grants = append(grants, g)
+			}
+		case "del":
+			if len(args) != 1 {
+				return usageError("acl del needs a principal")
+			}
+		default:
+			return usageError("unknown acl action %q, want get, set or del", action)
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		if action != "get" {
+			if err := c.SetACL(context.Background(), args[0], grants, kvclient.Timeout(timeout)); err != nil {
+				return err
+			}
+			newPrinter().done("OK")
+			return nil
+		}
+		principal := ""
+		if len(args) == 1 {
+			principal = args[0]
+		}
+		acls, err := c.ACLs(context.Background(), principal, kvclient.Timeout(timeout))
+		if err != nil {
+			return err
+		}
+		p := newPrinter("PRINCIPAL", "PERMISSION", "NAMESPACE", "PREFIX")
+		defer p.flush()
+		for _, acl := range acls {
+			for _, g := range acl.GetGrants() {
+				p.grant(acl.GetPrincipal(), g)
+			}
+		}
+		return nil
+	}
+}
+
+func setupNamespace(fs *flag.FlagSet) func(args []string) error {
+	maxKeys := fs.Int64("max_keys", 0, "ns set: most keys the namespace may hold, 0 for no limit")
+	maxBytes := fs.String("max_bytes", "0", "ns set: most bytes of keys and values, with an optional K, M or G suffix, 0 for no limit")
+	maxOps := fs.Int64("max_ops_per_sec", 0, "ns set: most calls a second on the namespace, 0 for no limit")
+	return func(args []string) error {
+		action := "get"
+		if len(args) > 0 {
+			action, args = args[0], args[1:]
+		}
+		quota := &pb.Quota{}
+		switch action {
+		case "get":
+		case "set", "del":
+			if len(args) != 1 {
+				return usageError("ns %s needs a namespace, \"\" for the default one", action)
+			}
+			quota.Namespace = args[0]
+			if action == "set" {
+				bytes, err := parseSize(*maxBytes)
+				if err != nil {
+					return usageError("%v", err)
+				}
+				quota.MaxKeys, quota.MaxBytes, quota.MaxOpsPerSec = *maxKeys, bytes, *maxOps
+			}
+		default:
+			return usageError("unknown ns action %q, want get, set or del", action)
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		if action != "get" {
+			if err := c.SetQuota(context.Background(), quota, kvclient.Timeout(timeout)); err != nil {
+				return err
+			}
+			newPrinter().done("OK")
+			return nil
+		}
+		stats, err := c.Namespaces(context.Background(), args, kvclient.Timeout(timeout))
+		if err != nil {
+			return err
+		}
+		p := newPrinter("NAMESPACE", "KEYS", "BYTES", "GETS", "SETS", "DELETES", "READS", "REJECTED", "MAX KEYS", "MAX BYTES", "MAX OPS/S")
+		defer p.flush()
+		for _, ns := range stats {
+			p.namespace(ns)
+		}
+		return nil
+	}
+}
+
+func setupLogLevel(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 1 {
+			return usageError("loglevel takes at most one level")
+		}
+		level := ""
+		if len(args) == 1 {
+			level = args[0]
+		}
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		previous, current, err := c.SetLogLevel(context.Background(), level, kvclient.Timeout(timeout))
+		if err != nil {
+			return err
+		}
+		p := newPrinter("LEVEL", "PREVIOUS")
+		defer p.flush()
+		p.logLevel(previous, current)
+		return nil
+	}
+}
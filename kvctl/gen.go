@@ -0,0 +1,257 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/bulk"
+)
+
+// letters of generated keys and values, no comma so every format can hold them
+const genLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// sizeDist draws sizes in [min, max]: always max, uniform, or zipfian with the
+// small sizes the most frequent
+type sizeDist struct {
+	kind string
+	min  int
+	max  int
+	zipf *rand.Zipf
+}
+
+func newSizeDist(rng *rand.Rand, kind string, min int, max int) (*sizeDist, error) {
+	if max < 1 || min < 1 || min > max {
+		return nil, fmt.Errorf("invalid size range %d to %d", min, max)
+	}
+	d := &sizeDist{kind: kind, min: min, max: max}
+	switch kind {
+	case "constant", "uniform":
+	case "zipfian":
+		d.zipf = rand.NewZipf(rng, 1.1, 1, uint64(max-min))
+	default:
+		return nil, fmt.Errorf("unknown size distribution %q, want constant, uniform or zipfian", kind)
+	}
+	return d, nil
+}
+
+func (d *sizeDist) next(rng *rand.Rand) int {
+	switch d.kind {
+	case "uniform":
+		return d.min + rng.Intn(d.max-d.min+1)
+	case "zipfian":
+		return d.min + int(d.zipf.Uint64())
+	}
+	return d.max
+}
+
+// genString is RandStringBytesMaskImpr of the client over genLetters
+func genString(rng *rand.Rand, b []byte) {
+	const idxBits = 6
+	const idxMask = 1<<idxBits - 1
+	const idxMax = 63 / idxBits
+	for i, cache, remain := len(b)-1, rng.Int63(), idxMax; i >= 0; {
+		if remain == 0 {
+			cache, remain = rng.Int63(), idxMax
+		}
+		if idx := int(cache & idxMask); idx < len(genLetters) {
+			b[i] = genLetters[idx]
+			i--
+		}
+		cache >>= idxBits
+		remain--
+	}
+}
+
+// parseSize reads a byte count with an optional K, M, G or T suffix
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+	unit := int64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		case 'T':
+			unit = 1 << 40
+		}
+		if unit > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
+
+func setupGen(fs *flag.FlagSet) func(args []string) error {
+	count := fs.Int64("count", 10000, "number of keys, 0 for no limit but -total_size")
+	totalSize := fs.String("total_size", "0", "stop once keys and values add up to this many bytes, e.g. 4G, 0 for no limit but -count")
+	keySize := fs.Int("key_size", 16, "key size in bytes, prefix included, the largest one if not constant")
+	keyMin := fs.Int("key_size_min", 0, "smallest key size of the uniform and zipfian distributions, enough for a unique key by default")
+	keyDist := fs.String("key_size_distribution", "constant", "key sizes, `constant`, `uniform` or `zipfian`")
+	valueSize := fs.Int("value_size", 512, "value size in bytes, the largest one if not constant")
+	valueMin := fs.Int("value_size_min", 1, "smallest value size of the uniform and zipfian distributions")
+	valueDist := fs.String("value_size_distribution", "constant", "value sizes, `constant`, `uniform` or `zipfian`")
+	prefixes := fs.String("prefixes", "", "comma separated key prefixes picked at random, e.g. user:,order:")
+	seed := fs.Int64("seed", 1, "seed of the generator, the same seed and flags give the same dataset")
+	format := formatFlag(fs, "")
+	file := fs.String("file", "", "write to `path` instead of stdout")
+	dataDir := fs.String("data_dir", "", "write the history.log of a server started in `dir`, which recovers the keys at start")
+	force := fs.Bool("force", false, "replace an existing history.log in -data_dir")
+	quiet := fs.Bool("quiet", false, "do not report progress on stderr")
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("gen takes no argument")
+		}
+		limit, err := parseSize(*totalSize)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		if *count <= 0 && limit == 0 {
+			return usageError("gen needs -count or -total_size")
+		}
+		var prefixList []string
+		for _, prefix := range strings.Split(*prefixes, ",") {
+			if prefix != "" {
+				prefixList = append(prefixList, prefix)
+			}
+		}
+		if len(prefixList) == 0 {
+			prefixList = []string{""}
+		}
+		longest := 0
+		for _, prefix := range prefixList {
+			if strings.ContainsAny(prefix, ",\n") {
+				return usageError("prefix %q holds a comma or a newline", prefix)
+			}
+			if len(prefix) > longest {
+				longest = len(prefix)
+			}
+		}
+
+		// every key ends with its index in base 36, so keys never collide
+		maxKeys := *count
+		if maxKeys <= 0 {
+			maxKeys = limit
+		}
+		digits := len(strconv.FormatInt(maxKeys, 36))
+		if *keyMin == 0 {
+			*keyMin = *keySize
+			if *keyDist != "constant" {
+				*keyMin = longest + digits
+			}
+		}
+		if *keyMin < longest+digits {
+			return usageError("keys of %d bytes cannot hold a prefix of %d bytes and %d unique digits", *keyMin, longest, digits)
+		}
+		rng := rand.New(rand.NewSource(*seed))
+		keySizes, err := newSizeDist(rng, *keyDist, *keyMin, *keySize)
+		if err != nil {
+			return usageError("key size: %v", err)
+		}
+		valueSizes, err := newSizeDist(rng, *valueDist, *valueMin, *valueSize)
+		if err != nil {
+			return usageError("value size: %v", err)
+		}
+
+		out := stdout
+		var tmp *os.File
+		target := *file
+		if *dataDir != "" {
+			if *file != "" {
+				return usageError("gen takes -file or -data_dir, not both")
+			}
+			if *format != "" && *format != "log" {
+				return usageError("-data_dir is written in the log format")
+			}
+			*format = "log"
+			if err := os.MkdirAll(*dataDir, 0755); err != nil {
+				return err
+			}
+			target = filepath.Join(*dataDir, "history.log")
+			if _, err := os.Stat(target); err == nil && !*force {
+				return fmt.Errorf("%s exists, use -force to replace it", target)
+			}
+		}
+		if *format == "" {
+			*format = "jsonl"
+			if target != "" {
+				*format = bulk.FormatOf(target)
+			}
+		}
+		if target != "" {
+			// write next to the target and rename, a failed run leaves no half file
+			f, err := ioutil.TempFile(filepath.Dir(target), filepath.Base(target)+".tmp")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			tmp = f
+			out = f
+		}
+		w, err := bulk.NewWriter(out, *format)
+		if err != nil {
+			return usageError("%v", err)
+		}
+
+		start := time.Now()
+		last := start
+		written := int64(0)
+		keyBuf := make([]byte, *keySize)
+		valueBuf := make([]byte, *valueSize)
+		i := int64(0)
+		for ; (*count <= 0 || i < *count) && (limit == 0 || written < limit); i++ {
+			prefix := prefixList[rng.Intn(len(prefixList))]
+			key := keyBuf[:keySizes.next(rng)]
+			copy(key, prefix)
+			genString(rng, key[len(prefix):])
+			index := strconv.FormatInt(i, 36)
+			copy(key[len(key)-digits:], strings.Repeat("0", digits-len(index))+index)
+			value := valueBuf[:valueSizes.next(rng)]
+			genString(rng, value)
+			// versions count from 1 so the same seed gives the same file
+			if err := w.Write(bulk.Record{Key: string(key), Value: string(value), Version: i + 1}); err != nil {
+				return err
+			}
+			written += int64(len(key) + len(value))
+			if !*quiet && time.Since(last) >= time.Second {
+				last = time.Now()
+				fmt.Fprintf(os.Stderr, "generated %d keys, %.1f MB\n", i+1, float64(written)/(1024*1024))
+			}
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		if tmp != nil {
+			if err := tmp.Sync(); err != nil {
+				return err
+			}
+			if err := tmp.Chmod(0644); err != nil {
+				return err
+			}
+			if err := tmp.Close(); err != nil {
+				return err
+			}
+			if err := os.Rename(tmp.Name(), target); err != nil {
+				return err
+			}
+		}
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "generated %d keys, %.1f MB in %s\n", i, float64(written)/(1024*1024), time.Since(start).Truncate(time.Millisecond))
+		}
+		return nil
+	}
+}
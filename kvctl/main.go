@@ -0,0 +1,162 @@
+// kvctl is the command line tool of the kvserver.
+//
+//	kvctl [flags] get|set|del|scan|watch|stats|import|export|gen|acl|ns|loglevel [flags] [args]
+//
+// Without a command and with a terminal on stdin it starts a shell with
+// history and tab completion.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
+	"github.com/ss87021456/gRPC-KVStore/tlsconfig"
+	"google.golang.org/grpc"
+)
+
+// exit codes
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitUnavailable = 4
+	exitDenied      = 5
+)
+
+var endpoints = "localhost:6000"
+var output = "table"
+var timeout = 10 * time.Second
+var maxMsgSize = 1024 * 1024 * 16
+var useTLS = false
+var tlsCA = ""
+var tlsCert = ""
+var tlsKey = ""
+var token = ""
+var namespace = ""
+
+var client *kvclient.Client
+var stdout io.Writer = os.Stdout
+var stdin io.Reader = os.Stdin
+
+// codeError carries the exit code of a failed command
+type codeError struct {
+	code int
+	err  error
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+func usageError(format string, a ...interface{}) error {
+	return &codeError{code: exitUsage, err: fmt.Errorf(format, a...)}
+}
+
+// exitCode maps the error of a command to the exit code of kvctl
+func exitCode(err error) int {
+	var e *codeError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &e):
+		return e.code
+	case kvclient.IsNotFound(err):
+		return exitNotFound
+	case kvclient.IsPermissionDenied(err):
+		return exitDenied
+	case errors.Is(err, kvclient.ErrUnavailable), errors.Is(err, kvclient.ErrDeadlineExceeded):
+		return exitUnavailable
+	}
+	return exitError
+}
+
+// globalFlags are accepted before the command and after it
+func globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&endpoints, "endpoints", endpoints, "comma separated servers, e.g. host1:6000,host2:6000")
+	fs.StringVar(&output, "output", output, "output format, `json`, `table` or `raw`")
+	fs.DurationVar(&timeout, "timeout", timeout, "timeout of each request, 0 for none")
+	fs.BoolVar(&useTLS, "tls", useTLS, "dial the servers over TLS, implied by -tls_ca and -tls_cert")
+	fs.StringVar(&tlsCA, "tls_ca", tlsCA, "PEM CA certificates verifying the servers, the system roots if empty")
+	fs.StringVar(&tlsCert, "tls_cert", tlsCert, "PEM client certificate for servers requiring one")
+	fs.StringVar(&tlsKey, "tls_key", tlsKey, "PEM private key of -tls_cert")
+	fs.StringVar(&token, "token", token, "bearer token for servers checking them, $KVCTL_TOKEN by default")
+	fs.StringVar(&namespace, "namespace", namespace, "namespace of the keys, the default namespace if empty")
+}
+
+func connect() (*kvclient.Client, error) {
+	if client != nil {
+		return client, nil
+	}
+	addrs := strings.Split(endpoints, ",")
+	opts := []kvclient.Option{kvclient.WithEndpoints(addrs[1:]...),
+		kvclient.WithDialOptions(grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize), grpc.MaxCallSendMsgSize(maxMsgSize)))}
+	if useTLS || tlsCA != "" || tlsCert != "" {
+		// the shell keeps its client, so certificates are reloaded when they change
+		certs, err := tlsconfig.NewReloader(tlsconfig.Files{CertFile: tlsCert, KeyFile: tlsKey, CAFile: tlsCA}, tlsconfig.DefaultReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kvclient.WithTLS(certs.ClientConfig()))
+	}
+	if token == "" {
+		token = os.Getenv("KVCTL_TOKEN")
+	}
+	if token != "" {
+		opts = append(opts, kvclient.WithToken(token))
+	}
+	if namespace != "" {
+		opts = append(opts, kvclient.WithNamespace(namespace))
+	}
+	c, err := kvclient.New(addrs[0], opts...)
+	if err != nil {
+		return nil, err
+	}
+	client = c
+	return client, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: kvctl [flags] <command> [flags] [args]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %-24s %s\n", cmd.name, cmd.args, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nflags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nexit codes: 0 ok, 1 error, 2 usage, 3 not found, 4 server unavailable, 5 permission denied\n")
+}
+
+func main() {
+	globalFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	var err error
+	if flag.NArg() == 0 {
+		if !isTerminal(os.Stdin) {
+			usage()
+			os.Exit(exitUsage)
+		}
+		err = repl()
+	} else {
+		err = run(flag.Args())
+	}
+	if client != nil {
+		client.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kvctl: %v\n", err)
+	}
+	os.Exit(exitCode(err))
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+)
+
+// printer writes results in the format picked with -output:
+// json prints one object per line, table prints aligned columns under a
+// header, raw prints values only, as they are
+type printer struct {
+	headers []string
+	table   *tabwriter.Writer
+	header  bool
+	json    *json.Encoder
+}
+
+func newPrinter(headers ...string) *printer {
+	return &printer{headers: headers, table: tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0), json: json.NewEncoder(stdout)}
+}
+
+func (p *printer) row(cells ...string) {
+	if !p.header {
+		fmt.Fprintln(p.table, strings.Join(p.headers, "\t"))
+		p.header = true
+	}
+	for i, cell := range cells {
+		// keep one row per line whatever the value holds
+		if strings.ContainsAny(cell, "\t\n\r") || (cell != strings.TrimSpace(cell)) {
+			cells[i] = strconv.Quote(cell)
+		}
+	}
+	fmt.Fprintln(p.table, strings.Join(cells, "\t"))
+}
+
+func (p *printer) pair(key string, value string) {
+	switch output {
+	case "json":
+		p.json.Encode(map[string]string{"key": key, "value": value})
+	case "raw":
+		fmt.Fprintln(stdout, value)
+	default:
+		p.row(key, value)
+	}
+}
+
+// event prints a change seen by watch, raw prints the new values only
+func (p *printer) event(e *pb.Entry) {
+	kind := "PUT"
+	if e.GetDeleted() {
+		kind = "DELETE"
+	}
+	switch output {
+	case "json":
+		p.json.Encode(struct {
+			Type    string `json:"type"`
+			Key     string `json:"key"`
+			Value   string `json:"value,omitempty"`
+			Version int64  `json:"version"`
+		}{strings.ToLower(kind), e.GetKey(), e.GetValue(), e.GetVersion()})
+	case "raw":
+		if !e.GetDeleted() {
+			fmt.Fprintln(stdout, e.GetValue())
+		}
+	default:
+		p.row(kind, e.GetKey(), e.GetValue())
+	}
+}
+
+func (p *printer) member(m *pb.Member) {
+	lastSeen := "never"
+	if m.GetLastSeen() > 0 {
+		lastSeen = time.Unix(0, m.GetLastSeen()).Format(time.RFC3339)
+	}
+	switch output {
+	case "json":
+		p.json.Encode(map[string]string{"addr": m.GetAddr(), "state": m.GetState().String(), "last_seen": lastSeen})
+	case "raw":
+		fmt.Fprintln(stdout, m.GetAddr())
+	default:
+		p.row(m.GetAddr(), m.GetState().String(), lastSeen)
+	}
+}
+
+// grant prints one grant of an ACL, raw prints permission[@namespace]:prefix
+// as acl set takes it
+func (p *printer) grant(principal string, g *pb.Grant) {
+	perm := strings.ToLower(g.GetPermission().String())
+	switch output {
+	case "json":
+		p.json.Encode(map[string]string{"principal": principal, "permission": perm, "namespace": g.GetNamespace(), "prefix": g.GetPrefix()})
+	case "raw":
+		if g.GetNamespace() != "" {
+			perm += "@" + g.GetNamespace()
+		}
+		fmt.Fprintln(stdout, principal, perm+":"+g.GetPrefix())
+	default:
+		p.row(principal, perm, strconv.Quote(g.GetNamespace()), strconv.Quote(g.GetPrefix()))
+	}
+}
+
+// namespace prints the usage and quota of a namespace, raw prints its name
+func (p *printer) namespace(ns *pb.NamespaceStats) {
+	q := ns.GetQuota()
+	switch output {
+	case "json":
+		p.json.Encode(ns)
+	case "raw":
+		fmt.Fprintln(stdout, ns.GetNamespace())
+	default:
+		limit := func(n int64) string {
+			if n == 0 {
+				return "-"
+			}
+			return strconv.FormatInt(n, 10)
+		}
+		p.row(strconv.Quote(ns.GetNamespace()), strconv.FormatInt(ns.GetKeys(), 10), strconv.FormatInt(ns.GetBytes(), 10),
+			strconv.FormatInt(ns.GetGets(), 10), strconv.FormatInt(ns.GetSets(), 10), strconv.FormatInt(ns.GetDeletes(), 10),
+			strconv.FormatInt(ns.GetReads(), 10), strconv.FormatInt(ns.GetRejected(), 10),
+			limit(q.GetMaxKeys()), limit(q.GetMaxBytes()), limit(q.GetMaxOpsPerSec()))
+	}
+}
+
+// logLevel prints the level of the server log, raw prints the current one
+func (p *printer) logLevel(previous string, current string) {
+	switch output {
+	case "json":
+		p.json.Encode(map[string]string{"level": current, "previous": previous})
+	case "raw":
+		fmt.Fprintln(stdout, current)
+	default:
+		p.row(current, previous)
+	}
+}
+
+// stats prints the figures of a server one per row, raw as name value lines
+func (p *printer) stats(st *pb.StatsResponse) {
+	if output == "json" {
+		p.json.Encode(st)
+		return
+	}
+	lastSnap := "never"
+	if st.GetLastSnapTime() > 0 {
+		lastSnap = time.Unix(st.GetLastSnapTime(), 0).Format(time.RFC3339)
+	}
+	rows := [][2]string{
+		{"start_time", time.Unix(0, st.GetStartTime()).Format(time.RFC3339)},
+		{"uptime", (time.Duration(st.GetUptimeMs()) * time.Millisecond).String()},
+		{"recovery", (time.Duration(st.GetRecoveryMs()) * time.Millisecond).String()},
+		{"sets", strconv.FormatInt(st.GetSets(), 10)},
+		{"gets", strconv.FormatInt(st.GetGets(), 10)},
+		{"get_prefixes", strconv.FormatInt(st.GetGetPrefixes(), 10)},
+		{"deletes", strconv.FormatInt(st.GetDeletes(), 10)},
+		{"scans", strconv.FormatInt(st.GetScans(), 10)},
+		{"watches", strconv.FormatInt(st.GetWatches(), 10)},
+		{"keys", strconv.FormatInt(st.GetKeys(), 10)},
+		{"data_bytes", strconv.FormatInt(st.GetDataBytes(), 10)},
+		{"wal_size", strconv.FormatInt(st.GetWalSize(), 10)},
+		{"last_snapshot", lastSnap},
+		{"revision", strconv.FormatInt(st.GetRevision(), 10)},
+	}
+	for _, r := range rows {
+		if output == "raw" {
+			fmt.Fprintln(stdout, r[0], r[1])
+		} else {
+			p.row(r[0], r[1])
+		}
+	}
+}
+
+// done reports a command without other output, only tables get a message
+func (p *printer) done(msg string) {
+	if output == "table" {
+		fmt.Fprintln(stdout, msg)
+	}
+}
+
+func (p *printer) flush() {
+	p.table.Flush()
+}
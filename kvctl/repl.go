@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/peterh/liner"
+	"github.com/ss87021456/gRPC-KVStore/kvclient"
+)
+
+// completionLimit bounds the keys offered by tab completion
+const completionLimit = 64
+
+var errEnoughKeys = errors.New("enough keys")
+
+// splitArgs splits a line into arguments like a shell: single quotes keep
+// everything as is, double quotes and backslashes escape the next character
+// and \n, \t inside double quotes are a newline and a tab
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && r == 'n' {
+				r = '\n'
+			} else if quote == '"' && r == 't' {
+				r = '\t'
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("line ends with a backslash")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// complete offers command names for the first word and keys for the others
+func complete(line string) []string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || (len(fields) == 1 && !strings.HasSuffix(line, " ")) {
+		word := ""
+		if len(fields) == 1 {
+			word = fields[0]
+		}
+		var matches []string
+		for _, cmd := range commands {
+			if strings.HasPrefix(cmd.name, word) {
+				matches = append(matches, cmd.name+" ")
+			}
+		}
+		return matches
+	}
+	switch fields[0] {
+	case "get", "set", "del", "scan", "watch", "export":
+	default:
+		return nil
+	}
+	word := ""
+	if !strings.HasSuffix(line, " ") {
+		word = fields[len(fields)-1]
+	}
+	if strings.ContainsAny(word, "'\"\\") {
+		return nil
+	}
+	c, err := connect()
+	if err != nil {
+		return nil
+	}
+	head := line[:len(line)-len(word)]
+	var matches []string
+	c.Scan(context.Background(), word, func(key string, value string) error {
+		if strings.ContainsAny(key, " \t'\"\\") {
+			return nil
+		}
+		matches = append(matches, head+key)
+		if len(matches) >= completionLimit {
+			return errEnoughKeys
+		}
+		return nil
+	}, kvclient.Timeout(time.Second), kvclient.MaxAttempts(1))
+	sort.Strings(matches)
+	return matches
+}
+
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kvctl_history")
+}
+
+// repl reads commands from the terminal until exit or ctrl-d. Flags given to
+// a command only apply to it.
+func repl() error {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetCompleter(complete)
+	history := historyFile()
+	if f, err := os.Open(history); err == nil {
+		line.ReadHistory(f)
+		f.Close()
+	}
+	defer func() {
+		if history == "" {
+			return
+		}
+		if f, err := os.Create(history); err == nil {
+			line.WriteHistory(f)
+			f.Close()
+		}
+	}()
+
+	fmt.Println("kvctl shell, type help for the commands, exit or ctrl-d to leave")
+	for {
+		input, err := line.Prompt("kvctl> ")
+		if err == liner.ErrPromptAborted {
+			continue
+		}
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		args, err := splitArgs(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		line.AppendHistory(input)
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "help":
+			usage()
+			continue
+		}
+		savedEndpoints, savedOutput, savedTimeout := endpoints, output, timeout
+		if err := run(args); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		endpoints, output, timeout = savedEndpoints, savedOutput, savedTimeout
+	}
+}
@@ -22,6 +22,99 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// Read options
+// LINEARIZABLE - the read must observe every write acknowledged before it
+// BOUNDED_STALENESS - the read may be served by a replica lagging at most max_staleness_ms or min_revision
+// ANY - the read may be served by any replica regardless of its lag
+type ReadConsistency int32
+
+const (
+	ReadConsistency_LINEARIZABLE      ReadConsistency = 0
+	ReadConsistency_BOUNDED_STALENESS ReadConsistency = 1
+	ReadConsistency_ANY               ReadConsistency = 2
+)
+
+var ReadConsistency_name = map[int32]string{
+	0: "LINEARIZABLE",
+	1: "BOUNDED_STALENESS",
+	2: "ANY",
+}
+
+var ReadConsistency_value = map[string]int32{
+	"LINEARIZABLE":      0,
+	"BOUNDED_STALENESS": 1,
+	"ANY":               2,
+}
+
+func (x ReadConsistency) String() string {
+	return proto.EnumName(ReadConsistency_name, int32(x))
+}
+
+func (ReadConsistency) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{0}
+}
+
+// Members
+type MemberState int32
+
+const (
+	MemberState_ALIVE   MemberState = 0
+	MemberState_SUSPECT MemberState = 1
+	MemberState_DEAD    MemberState = 2
+)
+
+var MemberState_name = map[int32]string{
+	0: "ALIVE",
+	1: "SUSPECT",
+	2: "DEAD",
+}
+
+var MemberState_value = map[string]int32{
+	"ALIVE":   0,
+	"SUSPECT": 1,
+	"DEAD":    2,
+}
+
+func (x MemberState) String() string {
+	return proto.EnumName(MemberState_name, int32(x))
+}
+
+func (MemberState) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{1}
+}
+
+// Access control
+type Permission int32
+
+const (
+	Permission_NONE  Permission = 0
+	Permission_READ  Permission = 1
+	Permission_WRITE Permission = 2
+	Permission_ADMIN Permission = 3
+)
+
+var Permission_name = map[int32]string{
+	0: "NONE",
+	1: "READ",
+	2: "WRITE",
+	3: "ADMIN",
+}
+
+var Permission_value = map[string]int32{
+	"NONE":  0,
+	"READ":  1,
+	"WRITE": 2,
+	"ADMIN": 3,
+}
+
+func (x Permission) String() string {
+	return proto.EnumName(Permission_name, int32(x))
+}
+
+func (Permission) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{2}
+}
+
 type Empty struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -53,10 +146,70 @@ func (m *Empty) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Empty proto.InternalMessageInfo
 
+// Quorum turns on leaderless replication for a request: the key is stored on
+// the n servers that own it, a set succeeds once w of them acknowledged it and
+// a get returns the newest version among r of them. n = 0 keeps the key local.
+type Quorum struct {
+	N                    uint32   `protobuf:"varint,1,opt,name=n,proto3" json:"n,omitempty"`
+	R                    uint32   `protobuf:"varint,2,opt,name=r,proto3" json:"r,omitempty"`
+	W                    uint32   `protobuf:"varint,3,opt,name=w,proto3" json:"w,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Quorum) Reset()         { *m = Quorum{} }
+func (m *Quorum) String() string { return proto.CompactTextString(m) }
+func (*Quorum) ProtoMessage()    {}
+func (*Quorum) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{1}
+}
+
+func (m *Quorum) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Quorum.Unmarshal(m, b)
+}
+func (m *Quorum) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Quorum.Marshal(b, m, deterministic)
+}
+func (m *Quorum) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Quorum.Merge(m, src)
+}
+func (m *Quorum) XXX_Size() int {
+	return xxx_messageInfo_Quorum.Size(m)
+}
+func (m *Quorum) XXX_DiscardUnknown() {
+	xxx_messageInfo_Quorum.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Quorum proto.InternalMessageInfo
+
+func (m *Quorum) GetN() uint32 {
+	if m != nil {
+		return m.N
+	}
+	return 0
+}
+
+func (m *Quorum) GetR() uint32 {
+	if m != nil {
+		return m.R
+	}
+	return 0
+}
+
+func (m *Quorum) GetW() uint32 {
+	if m != nil {
+		return m.W
+	}
+	return 0
+}
+
 // Set
 type SetRequest struct {
 	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Quorum               *Quorum  `protobuf:"bytes,3,opt,name=quorum,proto3" json:"quorum,omitempty"`
+	Namespace            string   `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -66,7 +219,7 @@ func (m *SetRequest) Reset()         { *m = SetRequest{} }
 func (m *SetRequest) String() string { return proto.CompactTextString(m) }
 func (*SetRequest) ProtoMessage()    {}
 func (*SetRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_088d7f6aff848d9e, []int{1}
+	return fileDescriptor_088d7f6aff848d9e, []int{2}
 }
 
 func (m *SetRequest) XXX_Unmarshal(b []byte) error {
@@ -101,19 +254,245 @@ func (m *SetRequest) GetValue() string {
 	return ""
 }
 
-// Get
-type GetRequest struct {
+func (m *SetRequest) GetQuorum() *Quorum {
+	if m != nil {
+		return m.Quorum
+	}
+	return nil
+}
+
+func (m *SetRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+// Delete
+type DeleteRequest struct {
 	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Quorum               *Quorum  `protobuf:"bytes,2,opt,name=quorum,proto3" json:"quorum,omitempty"`
+	Namespace            string   `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()    {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{3}
+}
+
+func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+}
+func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteRequest.Merge(m, src)
+}
+func (m *DeleteRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteRequest.Size(m)
+}
+func (m *DeleteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+
+func (m *DeleteRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *DeleteRequest) GetQuorum() *Quorum {
+	if m != nil {
+		return m.Quorum
+	}
+	return nil
+}
+
+func (m *DeleteRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+// Watch
+// streams every change of the keys starting with one of the prefixes, or of
+// every key if there is none. An event without entry is a heartbeat, sent at
+// least every second so watchers know how fresh their view is.
+type WatchRequest struct {
+	Prefixes             []string `protobuf:"bytes,1,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	Namespace            string   `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchRequest) Reset()         { *m = WatchRequest{} }
+func (m *WatchRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()    {}
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{4}
+}
+
+func (m *WatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchRequest.Unmarshal(m, b)
+}
+func (m *WatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchRequest.Merge(m, src)
+}
+func (m *WatchRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchRequest.Size(m)
+}
+func (m *WatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchRequest proto.InternalMessageInfo
+
+func (m *WatchRequest) GetPrefixes() []string {
+	if m != nil {
+		return m.Prefixes
+	}
+	return nil
+}
+
+func (m *WatchRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+type WatchEvent struct {
+	Entry                *Entry   `protobuf:"bytes,1,opt,name=entry,proto3" json:"entry,omitempty"`
+	Revision             int64    `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
+func (m *WatchEvent) Reset()         { *m = WatchEvent{} }
+func (m *WatchEvent) String() string { return proto.CompactTextString(m) }
+func (*WatchEvent) ProtoMessage()    {}
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{5}
+}
+
+func (m *WatchEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchEvent.Unmarshal(m, b)
+}
+func (m *WatchEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchEvent.Marshal(b, m, deterministic)
+}
+func (m *WatchEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchEvent.Merge(m, src)
+}
+func (m *WatchEvent) XXX_Size() int {
+	return xxx_messageInfo_WatchEvent.Size(m)
+}
+func (m *WatchEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchEvent proto.InternalMessageInfo
+
+func (m *WatchEvent) GetEntry() *Entry {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+func (m *WatchEvent) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+type ReadOptions struct {
+	Consistency          ReadConsistency `protobuf:"varint,1,opt,name=consistency,proto3,enum=kv.ReadConsistency" json:"consistency,omitempty"`
+	MaxStalenessMs       int64           `protobuf:"varint,2,opt,name=max_staleness_ms,json=maxStalenessMs,proto3" json:"max_staleness_ms,omitempty"`
+	MinRevision          int64           `protobuf:"varint,3,opt,name=min_revision,json=minRevision,proto3" json:"min_revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *ReadOptions) Reset()         { *m = ReadOptions{} }
+func (m *ReadOptions) String() string { return proto.CompactTextString(m) }
+func (*ReadOptions) ProtoMessage()    {}
+func (*ReadOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{6}
+}
+
+func (m *ReadOptions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReadOptions.Unmarshal(m, b)
+}
+func (m *ReadOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReadOptions.Marshal(b, m, deterministic)
+}
+func (m *ReadOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadOptions.Merge(m, src)
+}
+func (m *ReadOptions) XXX_Size() int {
+	return xxx_messageInfo_ReadOptions.Size(m)
+}
+func (m *ReadOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReadOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReadOptions proto.InternalMessageInfo
+
+func (m *ReadOptions) GetConsistency() ReadConsistency {
+	if m != nil {
+		return m.Consistency
+	}
+	return ReadConsistency_LINEARIZABLE
+}
+
+func (m *ReadOptions) GetMaxStalenessMs() int64 {
+	if m != nil {
+		return m.MaxStalenessMs
+	}
+	return 0
+}
+
+func (m *ReadOptions) GetMinRevision() int64 {
+	if m != nil {
+		return m.MinRevision
+	}
+	return 0
+}
+
+// Get
+type GetRequest struct {
+	Key                  string       `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ReadOptions          *ReadOptions `protobuf:"bytes,2,opt,name=read_options,json=readOptions,proto3" json:"read_options,omitempty"`
+	Quorum               *Quorum      `protobuf:"bytes,3,opt,name=quorum,proto3" json:"quorum,omitempty"`
+	Namespace            string       `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
 func (m *GetRequest) Reset()         { *m = GetRequest{} }
 func (m *GetRequest) String() string { return proto.CompactTextString(m) }
 func (*GetRequest) ProtoMessage()    {}
 func (*GetRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_088d7f6aff848d9e, []int{2}
+	return fileDescriptor_088d7f6aff848d9e, []int{7}
 }
 
 func (m *GetRequest) XXX_Unmarshal(b []byte) error {
@@ -141,8 +520,31 @@ func (m *GetRequest) GetKey() string {
 	return ""
 }
 
+func (m *GetRequest) GetReadOptions() *ReadOptions {
+	if m != nil {
+		return m.ReadOptions
+	}
+	return nil
+}
+
+func (m *GetRequest) GetQuorum() *Quorum {
+	if m != nil {
+		return m.Quorum
+	}
+	return nil
+}
+
+func (m *GetRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
 type GetResponse struct {
 	Value                string   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Revision             int64    `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	StalenessMs          int64    `protobuf:"varint,3,opt,name=staleness_ms,json=stalenessMs,proto3" json:"staleness_ms,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -152,7 +554,7 @@ func (m *GetResponse) Reset()         { *m = GetResponse{} }
 func (m *GetResponse) String() string { return proto.CompactTextString(m) }
 func (*GetResponse) ProtoMessage()    {}
 func (*GetResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_088d7f6aff848d9e, []int{3}
+	return fileDescriptor_088d7f6aff848d9e, []int{8}
 }
 
 func (m *GetResponse) XXX_Unmarshal(b []byte) error {
@@ -180,19 +582,35 @@ func (m *GetResponse) GetValue() string {
 	return ""
 }
 
+func (m *GetResponse) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *GetResponse) GetStalenessMs() int64 {
+	if m != nil {
+		return m.StalenessMs
+	}
+	return 0
+}
+
 // GetPrefix
 type GetPrefixRequest struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Key                  string       `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ReadOptions          *ReadOptions `protobuf:"bytes,2,opt,name=read_options,json=readOptions,proto3" json:"read_options,omitempty"`
+	Namespace            string       `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *GetPrefixRequest) Reset()         { *m = GetPrefixRequest{} }
 func (m *GetPrefixRequest) String() string { return proto.CompactTextString(m) }
 func (*GetPrefixRequest) ProtoMessage()    {}
 func (*GetPrefixRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_088d7f6aff848d9e, []int{4}
+	return fileDescriptor_088d7f6aff848d9e, []int{9}
 }
 
 func (m *GetPrefixRequest) XXX_Unmarshal(b []byte) error {
@@ -220,8 +638,24 @@ func (m *GetPrefixRequest) GetKey() string {
 	return ""
 }
 
+func (m *GetPrefixRequest) GetReadOptions() *ReadOptions {
+	if m != nil {
+		return m.ReadOptions
+	}
+	return nil
+}
+
+func (m *GetPrefixRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
 type GetPrefixResponse struct {
 	Values               []string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	Revision             int64    `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	StalenessMs          int64    `protobuf:"varint,3,opt,name=staleness_ms,json=stalenessMs,proto3" json:"staleness_ms,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -231,7 +665,7 @@ func (m *GetPrefixResponse) Reset()         { *m = GetPrefixResponse{} }
 func (m *GetPrefixResponse) String() string { return proto.CompactTextString(m) }
 func (*GetPrefixResponse) ProtoMessage()    {}
 func (*GetPrefixResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_088d7f6aff848d9e, []int{5}
+	return fileDescriptor_088d7f6aff848d9e, []int{10}
 }
 
 func (m *GetPrefixResponse) XXX_Unmarshal(b []byte) error {
@@ -259,63 +693,1826 @@ func (m *GetPrefixResponse) GetValues() []string {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*Empty)(nil), "kv.Empty")
-	proto.RegisterType((*SetRequest)(nil), "kv.SetRequest")
-	proto.RegisterType((*GetRequest)(nil), "kv.GetRequest")
-	proto.RegisterType((*GetResponse)(nil), "kv.GetResponse")
-	proto.RegisterType((*GetPrefixRequest)(nil), "kv.GetPrefixRequest")
-	proto.RegisterType((*GetPrefixResponse)(nil), "kv.GetPrefixResponse")
+func (m *GetPrefixResponse) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
 }
 
-func init() { proto.RegisterFile("kvstore.proto", fileDescriptor_088d7f6aff848d9e) }
-
-var fileDescriptor_088d7f6aff848d9e = []byte{
-	// 231 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0xcd, 0x2e, 0x2b, 0x2e,
-	0xc9, 0x2f, 0x4a, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0xca, 0x2e, 0x53, 0x62, 0xe7,
-	0x62, 0x75, 0xcd, 0x2d, 0x28, 0xa9, 0x54, 0x32, 0xe1, 0xe2, 0x0a, 0x4e, 0x2d, 0x09, 0x4a, 0x2d,
-	0x2c, 0x4d, 0x2d, 0x2e, 0x11, 0x12, 0xe0, 0x62, 0xce, 0x4e, 0xad, 0x94, 0x60, 0x54, 0x60, 0xd4,
-	0xe0, 0x0c, 0x02, 0x31, 0x85, 0x44, 0xb8, 0x58, 0xcb, 0x12, 0x73, 0x4a, 0x53, 0x25, 0x98, 0xc0,
-	0x62, 0x10, 0x8e, 0x92, 0x1c, 0x17, 0x97, 0x3b, 0x1e, 0x5d, 0x4a, 0xca, 0x5c, 0xdc, 0x60, 0xf9,
-	0xe2, 0x82, 0xfc, 0xbc, 0xe2, 0x54, 0x84, 0x21, 0x8c, 0xc8, 0x86, 0xa8, 0x70, 0x09, 0xb8, 0xa7,
-	0x96, 0x04, 0x14, 0xa5, 0xa6, 0x65, 0x56, 0xe0, 0x36, 0x4a, 0x9b, 0x4b, 0x10, 0x49, 0x15, 0xd4,
-	0x40, 0x31, 0x2e, 0x36, 0xb0, 0x19, 0xc5, 0x12, 0x8c, 0x0a, 0xcc, 0x1a, 0x9c, 0x41, 0x50, 0x9e,
-	0xd1, 0x64, 0x46, 0x2e, 0x76, 0xef, 0xb0, 0x60, 0x90, 0x67, 0x85, 0x94, 0xb8, 0x98, 0x83, 0x53,
-	0x4b, 0x84, 0xf8, 0xf4, 0xb2, 0xcb, 0xf4, 0x10, 0x5e, 0x94, 0xe2, 0x04, 0xf1, 0x21, 0x7e, 0x67,
-	0x10, 0xd2, 0xe0, 0x62, 0x76, 0x87, 0xa9, 0x41, 0x78, 0x48, 0x8a, 0x1f, 0xce, 0x87, 0xd8, 0xa7,
-	0xc4, 0x20, 0x64, 0xc5, 0xc5, 0x09, 0x77, 0x86, 0x90, 0x08, 0x54, 0x1e, 0xc5, 0xed, 0x52, 0xa2,
-	0x68, 0xa2, 0x30, 0xbd, 0x49, 0x6c, 0xe0, 0x70, 0x37, 0x06, 0x04, 0x00, 0x00, 0xff, 0xff, 0xfb,
-	0x17, 0x92, 0x48, 0x88, 0x01, 0x00, 0x00,
+func (m *GetPrefixResponse) GetStalenessMs() int64 {
+	if m != nil {
+		return m.StalenessMs
+	}
+	return 0
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// KVStoreClient is the client API for KVStore service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type KVStoreClient interface {
-	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*Empty, error)
-	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
-	GetPrefix(ctx context.Context, in *GetPrefixRequest, opts ...grpc.CallOption) (*GetPrefixResponse, error)
+// Scan
+// streams the keys starting with prefix together with their values, in no
+// particular order
+type ScanRequest struct {
+	Prefix               string       `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	ReadOptions          *ReadOptions `protobuf:"bytes,2,opt,name=read_options,json=readOptions,proto3" json:"read_options,omitempty"`
+	Namespace            string       `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
-type kVStoreClient struct {
-	cc *grpc.ClientConn
+func (m *ScanRequest) Reset()         { *m = ScanRequest{} }
+func (m *ScanRequest) String() string { return proto.CompactTextString(m) }
+func (*ScanRequest) ProtoMessage()    {}
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{11}
 }
 
-func NewKVStoreClient(cc *grpc.ClientConn) KVStoreClient {
-	return &kVStoreClient{cc}
+func (m *ScanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanRequest.Unmarshal(m, b)
 }
-
-func (c *kVStoreClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (m *ScanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanRequest.Marshal(b, m, deterministic)
+}
+func (m *ScanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanRequest.Merge(m, src)
+}
+func (m *ScanRequest) XXX_Size() int {
+	return xxx_messageInfo_ScanRequest.Size(m)
+}
+func (m *ScanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanRequest proto.InternalMessageInfo
+
+func (m *ScanRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *ScanRequest) GetReadOptions() *ReadOptions {
+	if m != nil {
+		return m.ReadOptions
+	}
+	return nil
+}
+
+func (m *ScanRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+// Entry is a key with its value and version, later versions win
+type Entry struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Version              int64    `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Deleted              bool     `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Replicas             uint32   `protobuf:"varint,5,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Entry) Reset()         { *m = Entry{} }
+func (m *Entry) String() string { return proto.CompactTextString(m) }
+func (*Entry) ProtoMessage()    {}
+func (*Entry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{12}
+}
+
+func (m *Entry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Entry.Unmarshal(m, b)
+}
+func (m *Entry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Entry.Marshal(b, m, deterministic)
+}
+func (m *Entry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Entry.Merge(m, src)
+}
+func (m *Entry) XXX_Size() int {
+	return xxx_messageInfo_Entry.Size(m)
+}
+func (m *Entry) XXX_DiscardUnknown() {
+	xxx_messageInfo_Entry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Entry proto.InternalMessageInfo
+
+func (m *Entry) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Entry) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *Entry) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Entry) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+func (m *Entry) GetReplicas() uint32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+// MerkleTree
+// nodes are laid out as a heap: nodes[0] is the root and the children of
+// nodes[i] are nodes[2i+1] and nodes[2i+2]; the leaves cover 2^depth key buckets
+// only the keys both the server and from hold are covered, all of them if from is empty
+type MerkleTreeRequest struct {
+	Depth                uint32   `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	From                 string   `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleTreeRequest) Reset()         { *m = MerkleTreeRequest{} }
+func (m *MerkleTreeRequest) String() string { return proto.CompactTextString(m) }
+func (*MerkleTreeRequest) ProtoMessage()    {}
+func (*MerkleTreeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{13}
+}
+
+func (m *MerkleTreeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleTreeRequest.Unmarshal(m, b)
+}
+func (m *MerkleTreeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleTreeRequest.Marshal(b, m, deterministic)
+}
+func (m *MerkleTreeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleTreeRequest.Merge(m, src)
+}
+func (m *MerkleTreeRequest) XXX_Size() int {
+	return xxx_messageInfo_MerkleTreeRequest.Size(m)
+}
+func (m *MerkleTreeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleTreeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleTreeRequest proto.InternalMessageInfo
+
+func (m *MerkleTreeRequest) GetDepth() uint32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *MerkleTreeRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+type MerkleTreeResponse struct {
+	Depth                uint32   `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	Nodes                [][]byte `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleTreeResponse) Reset()         { *m = MerkleTreeResponse{} }
+func (m *MerkleTreeResponse) String() string { return proto.CompactTextString(m) }
+func (*MerkleTreeResponse) ProtoMessage()    {}
+func (*MerkleTreeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{14}
+}
+
+func (m *MerkleTreeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleTreeResponse.Unmarshal(m, b)
+}
+func (m *MerkleTreeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleTreeResponse.Marshal(b, m, deterministic)
+}
+func (m *MerkleTreeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleTreeResponse.Merge(m, src)
+}
+func (m *MerkleTreeResponse) XXX_Size() int {
+	return xxx_messageInfo_MerkleTreeResponse.Size(m)
+}
+func (m *MerkleTreeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleTreeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleTreeResponse proto.InternalMessageInfo
+
+func (m *MerkleTreeResponse) GetDepth() uint32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *MerkleTreeResponse) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+// GetBuckets
+type GetBucketsRequest struct {
+	Depth                uint32   `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	Buckets              []uint32 `protobuf:"varint,2,rep,packed,name=buckets,proto3" json:"buckets,omitempty"`
+	From                 string   `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBucketsRequest) Reset()         { *m = GetBucketsRequest{} }
+func (m *GetBucketsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBucketsRequest) ProtoMessage()    {}
+func (*GetBucketsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{15}
+}
+
+func (m *GetBucketsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBucketsRequest.Unmarshal(m, b)
+}
+func (m *GetBucketsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBucketsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetBucketsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBucketsRequest.Merge(m, src)
+}
+func (m *GetBucketsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetBucketsRequest.Size(m)
+}
+func (m *GetBucketsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBucketsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBucketsRequest proto.InternalMessageInfo
+
+func (m *GetBucketsRequest) GetDepth() uint32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *GetBucketsRequest) GetBuckets() []uint32 {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+func (m *GetBucketsRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+// Fetch
+type FetchRequest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FetchRequest) Reset()         { *m = FetchRequest{} }
+func (m *FetchRequest) String() string { return proto.CompactTextString(m) }
+func (*FetchRequest) ProtoMessage()    {}
+func (*FetchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{16}
+}
+
+func (m *FetchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FetchRequest.Unmarshal(m, b)
+}
+func (m *FetchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FetchRequest.Marshal(b, m, deterministic)
+}
+func (m *FetchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetchRequest.Merge(m, src)
+}
+func (m *FetchRequest) XXX_Size() int {
+	return xxx_messageInfo_FetchRequest.Size(m)
+}
+func (m *FetchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FetchRequest proto.InternalMessageInfo
+
+func (m *FetchRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type FetchResponse struct {
+	Entry                *Entry   `protobuf:"bytes,1,opt,name=entry,proto3" json:"entry,omitempty"`
+	Found                bool     `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FetchResponse) Reset()         { *m = FetchResponse{} }
+func (m *FetchResponse) String() string { return proto.CompactTextString(m) }
+func (*FetchResponse) ProtoMessage()    {}
+func (*FetchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{17}
+}
+
+func (m *FetchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FetchResponse.Unmarshal(m, b)
+}
+func (m *FetchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FetchResponse.Marshal(b, m, deterministic)
+}
+func (m *FetchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetchResponse.Merge(m, src)
+}
+func (m *FetchResponse) XXX_Size() int {
+	return xxx_messageInfo_FetchResponse.Size(m)
+}
+func (m *FetchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FetchResponse proto.InternalMessageInfo
+
+func (m *FetchResponse) GetEntry() *Entry {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+func (m *FetchResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+// TailLog
+// a position in the write-ahead log is only valid within the epoch it was read
+// in, the log is compacted on every restart and a new epoch begins
+type TailLogRequest struct {
+	Epoch                int64    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Offset               int64    `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Prefixes             []string `protobuf:"bytes,3,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TailLogRequest) Reset()         { *m = TailLogRequest{} }
+func (m *TailLogRequest) String() string { return proto.CompactTextString(m) }
+func (*TailLogRequest) ProtoMessage()    {}
+func (*TailLogRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{18}
+}
+
+func (m *TailLogRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TailLogRequest.Unmarshal(m, b)
+}
+func (m *TailLogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TailLogRequest.Marshal(b, m, deterministic)
+}
+func (m *TailLogRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TailLogRequest.Merge(m, src)
+}
+func (m *TailLogRequest) XXX_Size() int {
+	return xxx_messageInfo_TailLogRequest.Size(m)
+}
+func (m *TailLogRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TailLogRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TailLogRequest proto.InternalMessageInfo
+
+func (m *TailLogRequest) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *TailLogRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *TailLogRequest) GetPrefixes() []string {
+	if m != nil {
+		return m.Prefixes
+	}
+	return nil
+}
+
+type LogRecord struct {
+	Epoch                int64    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Offset               int64    `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	LogSize              int64    `protobuf:"varint,3,opt,name=log_size,json=logSize,proto3" json:"log_size,omitempty"`
+	Entry                *Entry   `protobuf:"bytes,4,opt,name=entry,proto3" json:"entry,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogRecord) Reset()         { *m = LogRecord{} }
+func (m *LogRecord) String() string { return proto.CompactTextString(m) }
+func (*LogRecord) ProtoMessage()    {}
+func (*LogRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{19}
+}
+
+func (m *LogRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogRecord.Unmarshal(m, b)
+}
+func (m *LogRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogRecord.Marshal(b, m, deterministic)
+}
+func (m *LogRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogRecord.Merge(m, src)
+}
+func (m *LogRecord) XXX_Size() int {
+	return xxx_messageInfo_LogRecord.Size(m)
+}
+func (m *LogRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogRecord proto.InternalMessageInfo
+
+func (m *LogRecord) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *LogRecord) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *LogRecord) GetLogSize() int64 {
+	if m != nil {
+		return m.LogSize
+	}
+	return 0
+}
+
+func (m *LogRecord) GetEntry() *Entry {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+// VerifyReplicas
+type VerifyReplicasRequest struct {
+	Peers                []string `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifyReplicasRequest) Reset()         { *m = VerifyReplicasRequest{} }
+func (m *VerifyReplicasRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyReplicasRequest) ProtoMessage()    {}
+func (*VerifyReplicasRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{20}
+}
+
+func (m *VerifyReplicasRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyReplicasRequest.Unmarshal(m, b)
+}
+func (m *VerifyReplicasRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyReplicasRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyReplicasRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyReplicasRequest.Merge(m, src)
+}
+func (m *VerifyReplicasRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyReplicasRequest.Size(m)
+}
+func (m *VerifyReplicasRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyReplicasRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyReplicasRequest proto.InternalMessageInfo
+
+func (m *VerifyReplicasRequest) GetPeers() []string {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+type KeyDiff struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	LocalVersion         int64    `protobuf:"varint,2,opt,name=local_version,json=localVersion,proto3" json:"local_version,omitempty"`
+	RemoteVersion        int64    `protobuf:"varint,3,opt,name=remote_version,json=remoteVersion,proto3" json:"remote_version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyDiff) Reset()         { *m = KeyDiff{} }
+func (m *KeyDiff) String() string { return proto.CompactTextString(m) }
+func (*KeyDiff) ProtoMessage()    {}
+func (*KeyDiff) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{21}
+}
+
+func (m *KeyDiff) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyDiff.Unmarshal(m, b)
+}
+func (m *KeyDiff) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyDiff.Marshal(b, m, deterministic)
+}
+func (m *KeyDiff) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyDiff.Merge(m, src)
+}
+func (m *KeyDiff) XXX_Size() int {
+	return xxx_messageInfo_KeyDiff.Size(m)
+}
+func (m *KeyDiff) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyDiff.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyDiff proto.InternalMessageInfo
+
+func (m *KeyDiff) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyDiff) GetLocalVersion() int64 {
+	if m != nil {
+		return m.LocalVersion
+	}
+	return 0
+}
+
+func (m *KeyDiff) GetRemoteVersion() int64 {
+	if m != nil {
+		return m.RemoteVersion
+	}
+	return 0
+}
+
+type PeerReport struct {
+	Peer                 string     `protobuf:"bytes,1,opt,name=peer,proto3" json:"peer,omitempty"`
+	InSync               bool       `protobuf:"varint,2,opt,name=in_sync,json=inSync,proto3" json:"in_sync,omitempty"`
+	Diffs                []*KeyDiff `protobuf:"bytes,3,rep,name=diffs,proto3" json:"diffs,omitempty"`
+	Error                string     `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *PeerReport) Reset()         { *m = PeerReport{} }
+func (m *PeerReport) String() string { return proto.CompactTextString(m) }
+func (*PeerReport) ProtoMessage()    {}
+func (*PeerReport) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{22}
+}
+
+func (m *PeerReport) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PeerReport.Unmarshal(m, b)
+}
+func (m *PeerReport) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PeerReport.Marshal(b, m, deterministic)
+}
+func (m *PeerReport) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PeerReport.Merge(m, src)
+}
+func (m *PeerReport) XXX_Size() int {
+	return xxx_messageInfo_PeerReport.Size(m)
+}
+func (m *PeerReport) XXX_DiscardUnknown() {
+	xxx_messageInfo_PeerReport.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PeerReport proto.InternalMessageInfo
+
+func (m *PeerReport) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *PeerReport) GetInSync() bool {
+	if m != nil {
+		return m.InSync
+	}
+	return false
+}
+
+func (m *PeerReport) GetDiffs() []*KeyDiff {
+	if m != nil {
+		return m.Diffs
+	}
+	return nil
+}
+
+func (m *PeerReport) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type VerifyReplicasResponse struct {
+	Reports              []*PeerReport `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *VerifyReplicasResponse) Reset()         { *m = VerifyReplicasResponse{} }
+func (m *VerifyReplicasResponse) String() string { return proto.CompactTextString(m) }
+func (*VerifyReplicasResponse) ProtoMessage()    {}
+func (*VerifyReplicasResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{23}
+}
+
+func (m *VerifyReplicasResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyReplicasResponse.Unmarshal(m, b)
+}
+func (m *VerifyReplicasResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyReplicasResponse.Marshal(b, m, deterministic)
+}
+func (m *VerifyReplicasResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyReplicasResponse.Merge(m, src)
+}
+func (m *VerifyReplicasResponse) XXX_Size() int {
+	return xxx_messageInfo_VerifyReplicasResponse.Size(m)
+}
+func (m *VerifyReplicasResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyReplicasResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyReplicasResponse proto.InternalMessageInfo
+
+func (m *VerifyReplicasResponse) GetReports() []*PeerReport {
+	if m != nil {
+		return m.Reports
+	}
+	return nil
+}
+
+// Ping
+// both sides send the latest version they applied, so that each server knows
+// how far it is behind its peers
+type PingRequest struct {
+	From                 string   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	LastWrite            int64    `protobuf:"varint,2,opt,name=last_write,json=lastWrite,proto3" json:"last_write,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PingRequest) Reset()         { *m = PingRequest{} }
+func (m *PingRequest) String() string { return proto.CompactTextString(m) }
+func (*PingRequest) ProtoMessage()    {}
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{24}
+}
+
+func (m *PingRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PingRequest.Unmarshal(m, b)
+}
+func (m *PingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PingRequest.Marshal(b, m, deterministic)
+}
+func (m *PingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PingRequest.Merge(m, src)
+}
+func (m *PingRequest) XXX_Size() int {
+	return xxx_messageInfo_PingRequest.Size(m)
+}
+func (m *PingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PingRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PingRequest proto.InternalMessageInfo
+
+func (m *PingRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *PingRequest) GetLastWrite() int64 {
+	if m != nil {
+		return m.LastWrite
+	}
+	return 0
+}
+
+type PingResponse struct {
+	LastWrite            int64    `protobuf:"varint,1,opt,name=last_write,json=lastWrite,proto3" json:"last_write,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PingResponse) Reset()         { *m = PingResponse{} }
+func (m *PingResponse) String() string { return proto.CompactTextString(m) }
+func (*PingResponse) ProtoMessage()    {}
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{25}
+}
+
+func (m *PingResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PingResponse.Unmarshal(m, b)
+}
+func (m *PingResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PingResponse.Marshal(b, m, deterministic)
+}
+func (m *PingResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PingResponse.Merge(m, src)
+}
+func (m *PingResponse) XXX_Size() int {
+	return xxx_messageInfo_PingResponse.Size(m)
+}
+func (m *PingResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PingResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PingResponse proto.InternalMessageInfo
+
+func (m *PingResponse) GetLastWrite() int64 {
+	if m != nil {
+		return m.LastWrite
+	}
+	return 0
+}
+
+type Member struct {
+	Addr                 string      `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	State                MemberState `protobuf:"varint,2,opt,name=state,proto3,enum=kv.MemberState" json:"state,omitempty"`
+	LastSeen             int64       `protobuf:"varint,3,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Since                int64       `protobuf:"varint,4,opt,name=since,proto3" json:"since,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *Member) Reset()         { *m = Member{} }
+func (m *Member) String() string { return proto.CompactTextString(m) }
+func (*Member) ProtoMessage()    {}
+func (*Member) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{26}
+}
+
+func (m *Member) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Member.Unmarshal(m, b)
+}
+func (m *Member) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Member.Marshal(b, m, deterministic)
+}
+func (m *Member) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Member.Merge(m, src)
+}
+func (m *Member) XXX_Size() int {
+	return xxx_messageInfo_Member.Size(m)
+}
+func (m *Member) XXX_DiscardUnknown() {
+	xxx_messageInfo_Member.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Member proto.InternalMessageInfo
+
+func (m *Member) GetAddr() string {
+	if m != nil {
+		return m.Addr
+	}
+	return ""
+}
+
+func (m *Member) GetState() MemberState {
+	if m != nil {
+		return m.State
+	}
+	return MemberState_ALIVE
+}
+
+func (m *Member) GetLastSeen() int64 {
+	if m != nil {
+		return m.LastSeen
+	}
+	return 0
+}
+
+func (m *Member) GetSince() int64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+type MembersRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MembersRequest) Reset()         { *m = MembersRequest{} }
+func (m *MembersRequest) String() string { return proto.CompactTextString(m) }
+func (*MembersRequest) ProtoMessage()    {}
+func (*MembersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{27}
+}
+
+func (m *MembersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MembersRequest.Unmarshal(m, b)
+}
+func (m *MembersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MembersRequest.Marshal(b, m, deterministic)
+}
+func (m *MembersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MembersRequest.Merge(m, src)
+}
+func (m *MembersRequest) XXX_Size() int {
+	return xxx_messageInfo_MembersRequest.Size(m)
+}
+func (m *MembersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MembersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MembersRequest proto.InternalMessageInfo
+
+type MembersResponse struct {
+	Members              []*Member `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *MembersResponse) Reset()         { *m = MembersResponse{} }
+func (m *MembersResponse) String() string { return proto.CompactTextString(m) }
+func (*MembersResponse) ProtoMessage()    {}
+func (*MembersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{28}
+}
+
+func (m *MembersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MembersResponse.Unmarshal(m, b)
+}
+func (m *MembersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MembersResponse.Marshal(b, m, deterministic)
+}
+func (m *MembersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MembersResponse.Merge(m, src)
+}
+func (m *MembersResponse) XXX_Size() int {
+	return xxx_messageInfo_MembersResponse.Size(m)
+}
+func (m *MembersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MembersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MembersResponse proto.InternalMessageInfo
+
+func (m *MembersResponse) GetMembers() []*Member {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+type Grant struct {
+	Prefix               string     `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Permission           Permission `protobuf:"varint,2,opt,name=permission,proto3,enum=kv.Permission" json:"permission,omitempty"`
+	Namespace            string     `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *Grant) Reset()         { *m = Grant{} }
+func (m *Grant) String() string { return proto.CompactTextString(m) }
+func (*Grant) ProtoMessage()    {}
+func (*Grant) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{29}
+}
+
+func (m *Grant) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Grant.Unmarshal(m, b)
+}
+func (m *Grant) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Grant.Marshal(b, m, deterministic)
+}
+func (m *Grant) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Grant.Merge(m, src)
+}
+func (m *Grant) XXX_Size() int {
+	return xxx_messageInfo_Grant.Size(m)
+}
+func (m *Grant) XXX_DiscardUnknown() {
+	xxx_messageInfo_Grant.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Grant proto.InternalMessageInfo
+
+func (m *Grant) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *Grant) GetPermission() Permission {
+	if m != nil {
+		return m.Permission
+	}
+	return Permission_NONE
+}
+
+func (m *Grant) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+// Acl is what one principal may do, an Acl without grants removes it
+type Acl struct {
+	Principal            string   `protobuf:"bytes,1,opt,name=principal,proto3" json:"principal,omitempty"`
+	Grants               []*Grant `protobuf:"bytes,2,rep,name=grants,proto3" json:"grants,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Acl) Reset()         { *m = Acl{} }
+func (m *Acl) String() string { return proto.CompactTextString(m) }
+func (*Acl) ProtoMessage()    {}
+func (*Acl) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{30}
+}
+
+func (m *Acl) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Acl.Unmarshal(m, b)
+}
+func (m *Acl) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Acl.Marshal(b, m, deterministic)
+}
+func (m *Acl) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Acl.Merge(m, src)
+}
+func (m *Acl) XXX_Size() int {
+	return xxx_messageInfo_Acl.Size(m)
+}
+func (m *Acl) XXX_DiscardUnknown() {
+	xxx_messageInfo_Acl.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Acl proto.InternalMessageInfo
+
+func (m *Acl) GetPrincipal() string {
+	if m != nil {
+		return m.Principal
+	}
+	return ""
+}
+
+func (m *Acl) GetGrants() []*Grant {
+	if m != nil {
+		return m.Grants
+	}
+	return nil
+}
+
+type GetAclsRequest struct {
+	Principal            string   `protobuf:"bytes,1,opt,name=principal,proto3" json:"principal,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAclsRequest) Reset()         { *m = GetAclsRequest{} }
+func (m *GetAclsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAclsRequest) ProtoMessage()    {}
+func (*GetAclsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{31}
+}
+
+func (m *GetAclsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAclsRequest.Unmarshal(m, b)
+}
+func (m *GetAclsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAclsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetAclsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAclsRequest.Merge(m, src)
+}
+func (m *GetAclsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAclsRequest.Size(m)
+}
+func (m *GetAclsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAclsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAclsRequest proto.InternalMessageInfo
+
+func (m *GetAclsRequest) GetPrincipal() string {
+	if m != nil {
+		return m.Principal
+	}
+	return ""
+}
+
+type GetAclsResponse struct {
+	Acls                 []*Acl   `protobuf:"bytes,1,rep,name=acls,proto3" json:"acls,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAclsResponse) Reset()         { *m = GetAclsResponse{} }
+func (m *GetAclsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetAclsResponse) ProtoMessage()    {}
+func (*GetAclsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{32}
+}
+
+func (m *GetAclsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAclsResponse.Unmarshal(m, b)
+}
+func (m *GetAclsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAclsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetAclsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAclsResponse.Merge(m, src)
+}
+func (m *GetAclsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetAclsResponse.Size(m)
+}
+func (m *GetAclsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAclsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAclsResponse proto.InternalMessageInfo
+
+func (m *GetAclsResponse) GetAcls() []*Acl {
+	if m != nil {
+		return m.Acls
+	}
+	return nil
+}
+
+// Namespaces
+// every KVStore request names a namespace, "" being the default one, and only
+// sees the keys of that namespace. A limit of 0 is no limit.
+type Quota struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	MaxKeys              int64    `protobuf:"varint,2,opt,name=max_keys,json=maxKeys,proto3" json:"max_keys,omitempty"`
+	MaxBytes             int64    `protobuf:"varint,3,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	MaxOpsPerSec         int64    `protobuf:"varint,4,opt,name=max_ops_per_sec,json=maxOpsPerSec,proto3" json:"max_ops_per_sec,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Quota) Reset()         { *m = Quota{} }
+func (m *Quota) String() string { return proto.CompactTextString(m) }
+func (*Quota) ProtoMessage()    {}
+func (*Quota) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{33}
+}
+
+func (m *Quota) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Quota.Unmarshal(m, b)
+}
+func (m *Quota) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Quota.Marshal(b, m, deterministic)
+}
+func (m *Quota) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Quota.Merge(m, src)
+}
+func (m *Quota) XXX_Size() int {
+	return xxx_messageInfo_Quota.Size(m)
+}
+func (m *Quota) XXX_DiscardUnknown() {
+	xxx_messageInfo_Quota.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Quota proto.InternalMessageInfo
+
+func (m *Quota) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Quota) GetMaxKeys() int64 {
+	if m != nil {
+		return m.MaxKeys
+	}
+	return 0
+}
+
+func (m *Quota) GetMaxBytes() int64 {
+	if m != nil {
+		return m.MaxBytes
+	}
+	return 0
+}
+
+func (m *Quota) GetMaxOpsPerSec() int64 {
+	if m != nil {
+		return m.MaxOpsPerSec
+	}
+	return 0
+}
+
+type GetNamespacesRequest struct {
+	Namespaces           []string `protobuf:"bytes,1,rep,name=namespaces,proto3" json:"namespaces,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetNamespacesRequest) Reset()         { *m = GetNamespacesRequest{} }
+func (m *GetNamespacesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetNamespacesRequest) ProtoMessage()    {}
+func (*GetNamespacesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{34}
+}
+
+func (m *GetNamespacesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetNamespacesRequest.Unmarshal(m, b)
+}
+func (m *GetNamespacesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetNamespacesRequest.Marshal(b, m, deterministic)
+}
+func (m *GetNamespacesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetNamespacesRequest.Merge(m, src)
+}
+func (m *GetNamespacesRequest) XXX_Size() int {
+	return xxx_messageInfo_GetNamespacesRequest.Size(m)
+}
+func (m *GetNamespacesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetNamespacesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetNamespacesRequest proto.InternalMessageInfo
+
+func (m *GetNamespacesRequest) GetNamespaces() []string {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+type NamespaceStats struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Quota     *Quota `protobuf:"bytes,2,opt,name=quota,proto3" json:"quota,omitempty"`
+	Keys      int64  `protobuf:"varint,3,opt,name=keys,proto3" json:"keys,omitempty"`
+	Bytes     int64  `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	// calls since the server started
+	Gets                 int64    `protobuf:"varint,5,opt,name=gets,proto3" json:"gets,omitempty"`
+	Sets                 int64    `protobuf:"varint,6,opt,name=sets,proto3" json:"sets,omitempty"`
+	Deletes              int64    `protobuf:"varint,7,opt,name=deletes,proto3" json:"deletes,omitempty"`
+	Reads                int64    `protobuf:"varint,8,opt,name=reads,proto3" json:"reads,omitempty"`
+	Rejected             int64    `protobuf:"varint,9,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NamespaceStats) Reset()         { *m = NamespaceStats{} }
+func (m *NamespaceStats) String() string { return proto.CompactTextString(m) }
+func (*NamespaceStats) ProtoMessage()    {}
+func (*NamespaceStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{35}
+}
+
+func (m *NamespaceStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NamespaceStats.Unmarshal(m, b)
+}
+func (m *NamespaceStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NamespaceStats.Marshal(b, m, deterministic)
+}
+func (m *NamespaceStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NamespaceStats.Merge(m, src)
+}
+func (m *NamespaceStats) XXX_Size() int {
+	return xxx_messageInfo_NamespaceStats.Size(m)
+}
+func (m *NamespaceStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_NamespaceStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NamespaceStats proto.InternalMessageInfo
+
+func (m *NamespaceStats) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *NamespaceStats) GetQuota() *Quota {
+	if m != nil {
+		return m.Quota
+	}
+	return nil
+}
+
+func (m *NamespaceStats) GetKeys() int64 {
+	if m != nil {
+		return m.Keys
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetBytes() int64 {
+	if m != nil {
+		return m.Bytes
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetGets() int64 {
+	if m != nil {
+		return m.Gets
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetSets() int64 {
+	if m != nil {
+		return m.Sets
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetDeletes() int64 {
+	if m != nil {
+		return m.Deletes
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetReads() int64 {
+	if m != nil {
+		return m.Reads
+	}
+	return 0
+}
+
+func (m *NamespaceStats) GetRejected() int64 {
+	if m != nil {
+		return m.Rejected
+	}
+	return 0
+}
+
+type GetNamespacesResponse struct {
+	Namespaces           []*NamespaceStats `protobuf:"bytes,1,rep,name=namespaces,proto3" json:"namespaces,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *GetNamespacesResponse) Reset()         { *m = GetNamespacesResponse{} }
+func (m *GetNamespacesResponse) String() string { return proto.CompactTextString(m) }
+func (*GetNamespacesResponse) ProtoMessage()    {}
+func (*GetNamespacesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{36}
+}
+
+func (m *GetNamespacesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetNamespacesResponse.Unmarshal(m, b)
+}
+func (m *GetNamespacesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetNamespacesResponse.Marshal(b, m, deterministic)
+}
+func (m *GetNamespacesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetNamespacesResponse.Merge(m, src)
+}
+func (m *GetNamespacesResponse) XXX_Size() int {
+	return xxx_messageInfo_GetNamespacesResponse.Size(m)
+}
+func (m *GetNamespacesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetNamespacesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetNamespacesResponse proto.InternalMessageInfo
+
+func (m *GetNamespacesResponse) GetNamespaces() []*NamespaceStats {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+// Stats
+// totals count the calls handled since the server started, failed ones included
+type StatsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsRequest) Reset()         { *m = StatsRequest{} }
+func (m *StatsRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsRequest) ProtoMessage()    {}
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{37}
+}
+
+func (m *StatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsRequest.Unmarshal(m, b)
+}
+func (m *StatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsRequest.Merge(m, src)
+}
+func (m *StatsRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsRequest.Size(m)
+}
+func (m *StatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsRequest proto.InternalMessageInfo
+
+type StatsResponse struct {
+	StartTime            int64    `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	UptimeMs             int64    `protobuf:"varint,2,opt,name=uptime_ms,json=uptimeMs,proto3" json:"uptime_ms,omitempty"`
+	RecoveryMs           int64    `protobuf:"varint,3,opt,name=recovery_ms,json=recoveryMs,proto3" json:"recovery_ms,omitempty"`
+	Sets                 int64    `protobuf:"varint,4,opt,name=sets,proto3" json:"sets,omitempty"`
+	Gets                 int64    `protobuf:"varint,5,opt,name=gets,proto3" json:"gets,omitempty"`
+	GetPrefixes          int64    `protobuf:"varint,6,opt,name=get_prefixes,json=getPrefixes,proto3" json:"get_prefixes,omitempty"`
+	Deletes              int64    `protobuf:"varint,7,opt,name=deletes,proto3" json:"deletes,omitempty"`
+	Scans                int64    `protobuf:"varint,8,opt,name=scans,proto3" json:"scans,omitempty"`
+	Watches              int64    `protobuf:"varint,9,opt,name=watches,proto3" json:"watches,omitempty"`
+	Keys                 int64    `protobuf:"varint,10,opt,name=keys,proto3" json:"keys,omitempty"`
+	DataBytes            int64    `protobuf:"varint,11,opt,name=data_bytes,json=dataBytes,proto3" json:"data_bytes,omitempty"`
+	WalSize              int64    `protobuf:"varint,12,opt,name=wal_size,json=walSize,proto3" json:"wal_size,omitempty"`
+	LastSnapTime         int64    `protobuf:"varint,13,opt,name=last_snap_time,json=lastSnapTime,proto3" json:"last_snap_time,omitempty"`
+	Revision             int64    `protobuf:"varint,14,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsResponse) Reset()         { *m = StatsResponse{} }
+func (m *StatsResponse) String() string { return proto.CompactTextString(m) }
+func (*StatsResponse) ProtoMessage()    {}
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{38}
+}
+
+func (m *StatsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsResponse.Unmarshal(m, b)
+}
+func (m *StatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsResponse.Marshal(b, m, deterministic)
+}
+func (m *StatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsResponse.Merge(m, src)
+}
+func (m *StatsResponse) XXX_Size() int {
+	return xxx_messageInfo_StatsResponse.Size(m)
+}
+func (m *StatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsResponse proto.InternalMessageInfo
+
+func (m *StatsResponse) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetUptimeMs() int64 {
+	if m != nil {
+		return m.UptimeMs
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetRecoveryMs() int64 {
+	if m != nil {
+		return m.RecoveryMs
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetSets() int64 {
+	if m != nil {
+		return m.Sets
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetGets() int64 {
+	if m != nil {
+		return m.Gets
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetGetPrefixes() int64 {
+	if m != nil {
+		return m.GetPrefixes
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetDeletes() int64 {
+	if m != nil {
+		return m.Deletes
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetScans() int64 {
+	if m != nil {
+		return m.Scans
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetWatches() int64 {
+	if m != nil {
+		return m.Watches
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetKeys() int64 {
+	if m != nil {
+		return m.Keys
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetDataBytes() int64 {
+	if m != nil {
+		return m.DataBytes
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetWalSize() int64 {
+	if m != nil {
+		return m.WalSize
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetLastSnapTime() int64 {
+	if m != nil {
+		return m.LastSnapTime
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+// SetLogLevel
+// level is debug, info, warn or error, empty only reads the current level
+type SetLogLevelRequest struct {
+	Level                string   `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLogLevelRequest) Reset()         { *m = SetLogLevelRequest{} }
+func (m *SetLogLevelRequest) String() string { return proto.CompactTextString(m) }
+func (*SetLogLevelRequest) ProtoMessage()    {}
+func (*SetLogLevelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{39}
+}
+
+func (m *SetLogLevelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLogLevelRequest.Unmarshal(m, b)
+}
+func (m *SetLogLevelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLogLevelRequest.Marshal(b, m, deterministic)
+}
+func (m *SetLogLevelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLogLevelRequest.Merge(m, src)
+}
+func (m *SetLogLevelRequest) XXX_Size() int {
+	return xxx_messageInfo_SetLogLevelRequest.Size(m)
+}
+func (m *SetLogLevelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLogLevelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLogLevelRequest proto.InternalMessageInfo
+
+func (m *SetLogLevelRequest) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+type SetLogLevelResponse struct {
+	Previous             string   `protobuf:"bytes,1,opt,name=previous,proto3" json:"previous,omitempty"`
+	Level                string   `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLogLevelResponse) Reset()         { *m = SetLogLevelResponse{} }
+func (m *SetLogLevelResponse) String() string { return proto.CompactTextString(m) }
+func (*SetLogLevelResponse) ProtoMessage()    {}
+func (*SetLogLevelResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_088d7f6aff848d9e, []int{40}
+}
+
+func (m *SetLogLevelResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLogLevelResponse.Unmarshal(m, b)
+}
+func (m *SetLogLevelResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLogLevelResponse.Marshal(b, m, deterministic)
+}
+func (m *SetLogLevelResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLogLevelResponse.Merge(m, src)
+}
+func (m *SetLogLevelResponse) XXX_Size() int {
+	return xxx_messageInfo_SetLogLevelResponse.Size(m)
+}
+func (m *SetLogLevelResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLogLevelResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLogLevelResponse proto.InternalMessageInfo
+
+func (m *SetLogLevelResponse) GetPrevious() string {
+	if m != nil {
+		return m.Previous
+	}
+	return ""
+}
+
+func (m *SetLogLevelResponse) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("kv.ReadConsistency", ReadConsistency_name, ReadConsistency_value)
+	proto.RegisterEnum("kv.MemberState", MemberState_name, MemberState_value)
+	proto.RegisterEnum("kv.Permission", Permission_name, Permission_value)
+	proto.RegisterType((*Empty)(nil), "kv.Empty")
+	proto.RegisterType((*Quorum)(nil), "kv.Quorum")
+	proto.RegisterType((*SetRequest)(nil), "kv.SetRequest")
+	proto.RegisterType((*DeleteRequest)(nil), "kv.DeleteRequest")
+	proto.RegisterType((*WatchRequest)(nil), "kv.WatchRequest")
+	proto.RegisterType((*WatchEvent)(nil), "kv.WatchEvent")
+	proto.RegisterType((*ReadOptions)(nil), "kv.ReadOptions")
+	proto.RegisterType((*GetRequest)(nil), "kv.GetRequest")
+	proto.RegisterType((*GetResponse)(nil), "kv.GetResponse")
+	proto.RegisterType((*GetPrefixRequest)(nil), "kv.GetPrefixRequest")
+	proto.RegisterType((*GetPrefixResponse)(nil), "kv.GetPrefixResponse")
+	proto.RegisterType((*ScanRequest)(nil), "kv.ScanRequest")
+	proto.RegisterType((*Entry)(nil), "kv.Entry")
+	proto.RegisterType((*MerkleTreeRequest)(nil), "kv.MerkleTreeRequest")
+	proto.RegisterType((*MerkleTreeResponse)(nil), "kv.MerkleTreeResponse")
+	proto.RegisterType((*GetBucketsRequest)(nil), "kv.GetBucketsRequest")
+	proto.RegisterType((*FetchRequest)(nil), "kv.FetchRequest")
+	proto.RegisterType((*FetchResponse)(nil), "kv.FetchResponse")
+	proto.RegisterType((*TailLogRequest)(nil), "kv.TailLogRequest")
+	proto.RegisterType((*LogRecord)(nil), "kv.LogRecord")
+	proto.RegisterType((*VerifyReplicasRequest)(nil), "kv.VerifyReplicasRequest")
+	proto.RegisterType((*KeyDiff)(nil), "kv.KeyDiff")
+	proto.RegisterType((*PeerReport)(nil), "kv.PeerReport")
+	proto.RegisterType((*VerifyReplicasResponse)(nil), "kv.VerifyReplicasResponse")
+	proto.RegisterType((*PingRequest)(nil), "kv.PingRequest")
+	proto.RegisterType((*PingResponse)(nil), "kv.PingResponse")
+	proto.RegisterType((*Member)(nil), "kv.Member")
+	proto.RegisterType((*MembersRequest)(nil), "kv.MembersRequest")
+	proto.RegisterType((*MembersResponse)(nil), "kv.MembersResponse")
+	proto.RegisterType((*Grant)(nil), "kv.Grant")
+	proto.RegisterType((*Acl)(nil), "kv.Acl")
+	proto.RegisterType((*GetAclsRequest)(nil), "kv.GetAclsRequest")
+	proto.RegisterType((*GetAclsResponse)(nil), "kv.GetAclsResponse")
+	proto.RegisterType((*Quota)(nil), "kv.Quota")
+	proto.RegisterType((*GetNamespacesRequest)(nil), "kv.GetNamespacesRequest")
+	proto.RegisterType((*NamespaceStats)(nil), "kv.NamespaceStats")
+	proto.RegisterType((*GetNamespacesResponse)(nil), "kv.GetNamespacesResponse")
+	proto.RegisterType((*StatsRequest)(nil), "kv.StatsRequest")
+	proto.RegisterType((*StatsResponse)(nil), "kv.StatsResponse")
+	proto.RegisterType((*SetLogLevelRequest)(nil), "kv.SetLogLevelRequest")
+	proto.RegisterType((*SetLogLevelResponse)(nil), "kv.SetLogLevelResponse")
+}
+
+func init() { proto.RegisterFile("kvstore.proto", fileDescriptor_088d7f6aff848d9e) }
+
+var fileDescriptor_088d7f6aff848d9e = []byte{
+	// 1898 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x6d, 0x6f, 0x1b, 0xc7,
+	0x11, 0x36, 0x79, 0x3c, 0xbe, 0xcc, 0x91, 0x14, 0xb5, 0x96, 0x14, 0x9a, 0xa9, 0x63, 0xfb, 0x1a,
+	0x17, 0x82, 0x0b, 0xab, 0x01, 0x9b, 0xa6, 0x40, 0x81, 0x00, 0xa6, 0x2c, 0x4a, 0x15, 0x2c, 0xc9,
+	0xca, 0x9d, 0x62, 0xa3, 0xf9, 0x42, 0x9c, 0x8e, 0x43, 0xe6, 0xa2, 0x7b, 0xf3, 0xed, 0x92, 0x12,
+	0xf3, 0xa5, 0x5f, 0xfa, 0x07, 0x02, 0xe4, 0x57, 0xf4, 0x37, 0xf4, 0x2f, 0xf5, 0x3f, 0x14, 0xfb,
+	0x72, 0x6f, 0xb4, 0x22, 0xa7, 0xa8, 0xfb, 0x8d, 0xf3, 0xec, 0xec, 0xec, 0xcc, 0xb3, 0x73, 0x33,
+	0xb3, 0x84, 0xce, 0xd5, 0x92, 0xb2, 0x28, 0xc1, 0xbd, 0x38, 0x89, 0x58, 0x44, 0xaa, 0x57, 0x4b,
+	0xb3, 0x01, 0xfa, 0x38, 0x88, 0xd9, 0xca, 0x1c, 0x42, 0xfd, 0x9b, 0x45, 0x94, 0x2c, 0x02, 0xd2,
+	0x86, 0x4a, 0xd8, 0xaf, 0x3c, 0xae, 0xec, 0x76, 0xac, 0x4a, 0xc8, 0xa5, 0xa4, 0x5f, 0x95, 0x52,
+	0xc2, 0xa5, 0xeb, 0xbe, 0x26, 0xa5, 0x6b, 0x73, 0x09, 0x60, 0x23, 0xb3, 0xf0, 0xdd, 0x02, 0x29,
+	0x23, 0x3d, 0xd0, 0xae, 0x70, 0x25, 0x76, 0xb6, 0x2c, 0xfe, 0x93, 0x6c, 0x81, 0xbe, 0x74, 0xfc,
+	0x05, 0x8a, 0xfd, 0x2d, 0x4b, 0x0a, 0xc4, 0x84, 0xfa, 0x3b, 0x71, 0x92, 0x30, 0x64, 0x0c, 0x61,
+	0xef, 0x6a, 0xb9, 0x27, 0xcf, 0xb6, 0xd4, 0x0a, 0xf9, 0x0d, 0xb4, 0x42, 0x27, 0x40, 0x1a, 0x3b,
+	0x2e, 0xf6, 0x6b, 0x62, 0x77, 0x0e, 0x98, 0x2e, 0x74, 0x0e, 0xd0, 0x47, 0x86, 0xbf, 0x7c, 0x74,
+	0x7e, 0x48, 0xf5, 0xd7, 0x1d, 0xa2, 0xad, 0x1f, 0xf2, 0x57, 0x68, 0xbf, 0x75, 0x98, 0xfb, 0x7d,
+	0x7a, 0xc6, 0x00, 0x9a, 0x71, 0x82, 0x33, 0xef, 0x06, 0x69, 0xbf, 0xf2, 0x58, 0xdb, 0x6d, 0x59,
+	0x99, 0x5c, 0xb6, 0x54, 0x5d, 0xb7, 0x74, 0x0c, 0x20, 0x2c, 0x8d, 0x97, 0x18, 0x32, 0xf2, 0x08,
+	0x74, 0x0c, 0x59, 0x22, 0xbd, 0x35, 0x86, 0x2d, 0xee, 0xd8, 0x98, 0x03, 0x96, 0xc4, 0xf9, 0x41,
+	0x09, 0x2e, 0x3d, 0xea, 0x45, 0xa1, 0xb0, 0xa5, 0x59, 0x99, 0x6c, 0xfe, 0x54, 0x01, 0xc3, 0x42,
+	0x67, 0xfa, 0x3a, 0x66, 0x5e, 0x14, 0x52, 0xf2, 0x27, 0x30, 0xdc, 0x28, 0xa4, 0x1e, 0x65, 0x18,
+	0xba, 0xd2, 0x64, 0x77, 0x78, 0x9f, 0x9b, 0xe4, 0x5a, 0x2f, 0xf3, 0x25, 0xab, 0xa8, 0x47, 0x76,
+	0xa1, 0x17, 0x38, 0x37, 0x13, 0xca, 0x1c, 0x1f, 0x43, 0xa4, 0x74, 0x12, 0x50, 0x75, 0x54, 0x37,
+	0x70, 0x6e, 0xec, 0x14, 0x3e, 0xa5, 0xe4, 0x09, 0xb4, 0x03, 0x2f, 0x9c, 0x64, 0x0e, 0x69, 0x42,
+	0xcb, 0x08, 0xbc, 0xd0, 0x4a, 0x7d, 0xfa, 0xb9, 0x02, 0x70, 0x74, 0x57, 0x1a, 0x0c, 0xa1, 0x9d,
+	0xa0, 0x33, 0x9d, 0x44, 0xd2, 0x69, 0x75, 0x23, 0x1b, 0xa9, 0x97, 0x2a, 0x16, 0xcb, 0x48, 0x72,
+	0xe1, 0x23, 0x24, 0xc9, 0x25, 0x18, 0xc2, 0x2b, 0x1a, 0x47, 0x21, 0xc5, 0x3c, 0x17, 0x2b, 0xc5,
+	0x5c, 0xbc, 0x83, 0x6b, 0x1e, 0x7a, 0x89, 0x20, 0x15, 0x3a, 0xcd, 0xd9, 0x31, 0x97, 0xd0, 0x3b,
+	0x42, 0x76, 0x2e, 0xd2, 0xe0, 0xe3, 0xc6, 0x7f, 0x77, 0x6e, 0xfe, 0x00, 0x9b, 0x85, 0x73, 0x55,
+	0x84, 0x3b, 0x50, 0x17, 0x41, 0xa5, 0xe9, 0xa9, 0xa4, 0xff, 0x35, 0xc6, 0x6b, 0x30, 0x6c, 0xd7,
+	0x09, 0xd3, 0xf0, 0x76, 0xa0, 0x2e, 0xd3, 0x5e, 0x45, 0xa8, 0xa4, 0xff, 0x43, 0x90, 0x7f, 0x07,
+	0x5d, 0x7c, 0x17, 0xbf, 0xba, 0xb0, 0xf4, 0xa1, 0xb1, 0xc4, 0xa4, 0x90, 0xa6, 0xa9, 0xc8, 0x57,
+	0xa6, 0xa2, 0x60, 0x4c, 0x45, 0x9e, 0x34, 0xad, 0x54, 0x94, 0xe4, 0xc4, 0xbe, 0xe7, 0x3a, 0xb4,
+	0xaf, 0x8b, 0xba, 0x96, 0xc9, 0xe6, 0xd7, 0xb0, 0x79, 0x8a, 0xc9, 0x95, 0x8f, 0x17, 0x09, 0x66,
+	0xa5, 0x66, 0x0b, 0xf4, 0x29, 0xc6, 0xec, 0x7b, 0x55, 0x21, 0xa5, 0x40, 0x08, 0xd4, 0x66, 0x49,
+	0x14, 0x28, 0x7f, 0xc4, 0x6f, 0xf3, 0x05, 0x90, 0xe2, 0xf6, 0x3c, 0x0f, 0x6f, 0xd9, 0xbf, 0x05,
+	0x7a, 0x18, 0x4d, 0x91, 0xd3, 0xa6, 0xed, 0xb6, 0x2d, 0x29, 0x98, 0x6f, 0xc5, 0x35, 0xef, 0x2f,
+	0xdc, 0x2b, 0x64, 0xf4, 0x6e, 0x07, 0xfa, 0xd0, 0xb8, 0x94, 0x7a, 0xc2, 0x44, 0xc7, 0x4a, 0xc5,
+	0xcc, 0x35, 0xad, 0xe0, 0xda, 0x63, 0x68, 0x1f, 0x62, 0xa1, 0xb6, 0xbd, 0xc7, 0xb0, 0x79, 0x08,
+	0x1d, 0xa5, 0xa1, 0xfc, 0xfe, 0x60, 0xd9, 0xda, 0x02, 0x7d, 0x16, 0x2d, 0xc2, 0xa9, 0xe0, 0xa0,
+	0x69, 0x49, 0xc1, 0xfc, 0x0e, 0xba, 0x17, 0x8e, 0xe7, 0x9f, 0x44, 0xf3, 0x82, 0xff, 0x18, 0x47,
+	0xae, 0xf4, 0x5f, 0xb3, 0xa4, 0xc0, 0xd3, 0x2a, 0x9a, 0xcd, 0x28, 0x32, 0x95, 0xa2, 0x4a, 0x2a,
+	0x55, 0x5d, 0xad, 0x5c, 0x75, 0xcd, 0x05, 0xb4, 0x84, 0x5d, 0x37, 0x4a, 0xa6, 0xff, 0xa5, 0xd9,
+	0x07, 0xd0, 0xf4, 0xa3, 0xf9, 0x84, 0x7a, 0x3f, 0x62, 0x9a, 0x2b, 0x7e, 0x34, 0xb7, 0xbd, 0x1f,
+	0x0b, 0x81, 0xd6, 0x6e, 0x0f, 0xd4, 0x7c, 0x0e, 0xdb, 0x6f, 0x30, 0xf1, 0x66, 0x2b, 0x4b, 0x25,
+	0x4a, 0x21, 0xb2, 0x18, 0x31, 0x49, 0xbf, 0x3f, 0x29, 0x98, 0x73, 0x68, 0xbc, 0xc2, 0xd5, 0x81,
+	0x37, 0x9b, 0xdd, 0x92, 0xc8, 0xbf, 0x85, 0x8e, 0x1f, 0xb9, 0x8e, 0x3f, 0x49, 0x13, 0x57, 0xba,
+	0xd9, 0x16, 0xe0, 0x1b, 0x89, 0x91, 0xa7, 0xd0, 0x4d, 0x30, 0x88, 0x18, 0x4e, 0xca, 0xe9, 0xdd,
+	0x91, 0xa8, 0x52, 0x33, 0x13, 0x80, 0x73, 0xc4, 0xc4, 0xc2, 0x38, 0x4a, 0x18, 0xbf, 0x76, 0x7e,
+	0xbe, 0x3a, 0x4c, 0xfc, 0x26, 0x9f, 0x40, 0xc3, 0x0b, 0x27, 0x74, 0x15, 0xba, 0xea, 0x92, 0xea,
+	0x5e, 0x68, 0xaf, 0x42, 0x97, 0x3c, 0x01, 0x7d, 0xea, 0xcd, 0x66, 0x92, 0x62, 0x63, 0x68, 0xf0,
+	0x98, 0x95, 0xd3, 0x96, 0x5c, 0x11, 0xfc, 0x26, 0x49, 0x94, 0xa8, 0x42, 0x2b, 0x05, 0x73, 0x1f,
+	0x76, 0xd6, 0xb9, 0x50, 0xf9, 0xb2, 0x0b, 0x8d, 0x44, 0x78, 0x22, 0xe9, 0x30, 0x86, 0x5d, 0x6e,
+	0x34, 0x77, 0xd0, 0x4a, 0x97, 0xcd, 0x17, 0x60, 0x9c, 0x7b, 0x61, 0x96, 0x1f, 0x69, 0xbe, 0x56,
+	0xf2, 0x7c, 0x25, 0x0f, 0x01, 0x7c, 0x87, 0xb2, 0xc9, 0x75, 0xe2, 0x31, 0x54, 0x1c, 0xb5, 0x38,
+	0xf2, 0x96, 0x03, 0xe6, 0x73, 0x68, 0x4b, 0x0b, 0xea, 0xec, 0xb2, 0x7a, 0x65, 0x5d, 0x7d, 0x09,
+	0xf5, 0x53, 0x0c, 0x2e, 0x31, 0xe1, 0x67, 0x39, 0xd3, 0x69, 0x46, 0x12, 0xff, 0x4d, 0x9e, 0x82,
+	0x4e, 0x99, 0xa3, 0x8e, 0xe9, 0xca, 0x0a, 0x26, 0xd5, 0x6d, 0x0e, 0x5b, 0x72, 0x95, 0x7c, 0x0a,
+	0xc2, 0xe2, 0x84, 0x22, 0xa6, 0xf7, 0xd1, 0xe4, 0x80, 0x8d, 0x18, 0x72, 0xb2, 0xa8, 0x17, 0xaa,
+	0xae, 0xa4, 0x59, 0x52, 0x30, 0x7b, 0xd0, 0x95, 0x86, 0xd2, 0x8c, 0x31, 0xff, 0x0c, 0x1b, 0x19,
+	0xa2, 0x7c, 0xff, 0x1c, 0x1a, 0x81, 0x84, 0x14, 0x6f, 0x90, 0x3b, 0x60, 0xa5, 0x4b, 0x66, 0x00,
+	0xfa, 0x51, 0xe2, 0x84, 0xbf, 0x5c, 0x8e, 0xf7, 0x00, 0x62, 0x4c, 0x02, 0x8f, 0x66, 0x59, 0xd5,
+	0x4d, 0x6f, 0x20, 0x45, 0xad, 0x82, 0xc6, 0x07, 0x4a, 0xf1, 0x21, 0x68, 0x23, 0xd7, 0xe7, 0x4a,
+	0x71, 0xe2, 0x85, 0xae, 0x17, 0x3b, 0xbe, 0x3a, 0x2f, 0x07, 0xc8, 0x13, 0xa8, 0xcf, 0xb9, 0x4f,
+	0xb2, 0x02, 0xa9, 0x2f, 0x47, 0x78, 0x69, 0xa9, 0x05, 0x73, 0x0f, 0xba, 0x47, 0xc8, 0x46, 0xae,
+	0x9f, 0x7d, 0x33, 0x77, 0x9a, 0x34, 0xf7, 0x60, 0x23, 0xd3, 0x57, 0xfc, 0x7c, 0x0a, 0x35, 0xc7,
+	0xf5, 0x53, 0x72, 0x1a, 0xfc, 0x8c, 0x91, 0xeb, 0x5b, 0x02, 0x34, 0xff, 0x51, 0x01, 0xfd, 0x9b,
+	0x45, 0xc4, 0x9c, 0x72, 0x3c, 0x95, 0xb5, 0x78, 0xf8, 0xe7, 0xcf, 0xe7, 0x9f, 0x2b, 0x5c, 0xa5,
+	0x73, 0x4f, 0x23, 0x70, 0x6e, 0x5e, 0xe1, 0x8a, 0xf2, 0x7b, 0xe5, 0x4b, 0x97, 0x2b, 0x86, 0x69,
+	0x3b, 0xe4, 0xba, 0xfb, 0x5c, 0x26, 0x4f, 0x61, 0x83, 0x2f, 0x46, 0x31, 0x9d, 0xc4, 0x98, 0x4c,
+	0x28, 0xba, 0xea, 0x86, 0xdb, 0x81, 0x73, 0xf3, 0x3a, 0xa6, 0xe7, 0x98, 0xd8, 0xe8, 0x9a, 0x5f,
+	0xc1, 0xd6, 0x11, 0xb2, 0xb3, 0xf4, 0xb8, 0x2c, 0xd8, 0xcf, 0x00, 0x32, 0x1f, 0xd2, 0x2a, 0x51,
+	0x40, 0xcc, 0x7f, 0x57, 0xa0, 0x9b, 0xed, 0xe2, 0xd9, 0x46, 0x3f, 0x10, 0xc7, 0x23, 0xd0, 0xdf,
+	0xf1, 0x70, 0x55, 0xb7, 0x6d, 0xa9, 0x21, 0x89, 0x39, 0x96, 0xc4, 0x79, 0x82, 0x8b, 0x20, 0x65,
+	0x20, 0xe2, 0x37, 0x4f, 0x4e, 0x19, 0x9d, 0x4a, 0x4e, 0x21, 0x70, 0xcd, 0x39, 0x32, 0xd9, 0x04,
+	0x35, 0x4b, 0xfc, 0xe6, 0x18, 0xe5, 0x58, 0x5d, 0x62, 0xfc, 0x77, 0xde, 0x4a, 0x69, 0xbf, 0x21,
+	0x99, 0x53, 0x22, 0xb7, 0xcb, 0x9b, 0x3b, 0xed, 0x37, 0xa5, 0x5d, 0x21, 0xc8, 0x06, 0xfb, 0x03,
+	0xba, 0xbc, 0xf7, 0xb6, 0xd2, 0xe9, 0x43, 0xca, 0xe6, 0x2b, 0xd8, 0x5e, 0xe3, 0x49, 0x5d, 0xf2,
+	0xf0, 0x3d, 0xa2, 0x8c, 0x21, 0xe1, 0xc1, 0x95, 0xd9, 0x29, 0x91, 0xd7, 0x85, 0xb6, 0x04, 0xd5,
+	0xb7, 0xf5, 0x93, 0x06, 0x1d, 0x05, 0xe4, 0x65, 0x81, 0x32, 0x27, 0x61, 0x13, 0xe6, 0x05, 0x59,
+	0x59, 0x10, 0xc8, 0x85, 0x17, 0x88, 0x2f, 0x7a, 0x11, 0xf3, 0xa5, 0x7c, 0x1a, 0x6e, 0x4a, 0xe0,
+	0x94, 0x92, 0x47, 0x60, 0x24, 0xe8, 0x46, 0x4b, 0x4c, 0x56, 0xf9, 0x9c, 0x04, 0x29, 0x74, 0x9a,
+	0x73, 0x55, 0x2b, 0x70, 0x75, 0x1b, 0xa7, 0x4f, 0xa0, 0x3d, 0x47, 0x36, 0xc9, 0x9a, 0x9a, 0xe4,
+	0xd6, 0x98, 0xa7, 0xe3, 0x1c, 0x7e, 0x80, 0x62, 0xea, 0x3a, 0x61, 0x46, 0xb1, 0x10, 0xb8, 0xfe,
+	0x35, 0x7f, 0x5f, 0x20, 0x55, 0x0c, 0xa7, 0x62, 0x76, 0xfd, 0x50, 0xb8, 0xfe, 0x87, 0x00, 0x53,
+	0x87, 0x39, 0x2a, 0xc3, 0x0d, 0xc9, 0x02, 0x47, 0x64, 0x8a, 0x3f, 0x80, 0xe6, 0xb5, 0xe3, 0xcb,
+	0xce, 0xd8, 0x4e, 0xad, 0xf9, 0xa2, 0x33, 0x7e, 0x0e, 0x5d, 0x59, 0xf2, 0x42, 0x27, 0x96, 0x1c,
+	0x76, 0x54, 0xb7, 0xe2, 0x75, 0x2f, 0x74, 0x62, 0x41, 0x63, 0x71, 0xdc, 0xec, 0xae, 0x3d, 0x5f,
+	0x9e, 0x01, 0xb1, 0x91, 0x9d, 0x44, 0xf3, 0x13, 0x5c, 0xa2, 0x5f, 0xe8, 0x9b, 0x3e, 0x97, 0xd3,
+	0xd1, 0x5c, 0x08, 0xe6, 0x11, 0xdc, 0x2f, 0xe9, 0xaa, 0x4b, 0x94, 0x03, 0xc1, 0xd2, 0x8b, 0x16,
+	0x54, 0xe9, 0x67, 0x72, 0x6e, 0xa8, 0x5a, 0x30, 0xf4, 0xec, 0x25, 0x6c, 0xac, 0x3d, 0x86, 0x48,
+	0x0f, 0xda, 0x27, 0xc7, 0x67, 0xe3, 0x91, 0x75, 0xfc, 0xdd, 0x68, 0xff, 0x64, 0xdc, 0xbb, 0x47,
+	0xb6, 0x61, 0x73, 0xff, 0xf5, 0xb7, 0x67, 0x07, 0xe3, 0x83, 0x89, 0x7d, 0x31, 0x3a, 0x19, 0x9f,
+	0x8d, 0x6d, 0xbb, 0x57, 0x21, 0x0d, 0xd0, 0x46, 0x67, 0x7f, 0xeb, 0x55, 0x9f, 0xfd, 0x01, 0x8c,
+	0x42, 0x13, 0x20, 0x2d, 0xd0, 0x47, 0x27, 0xc7, 0x6f, 0xf8, 0x4e, 0x03, 0x1a, 0xf6, 0xb7, 0xf6,
+	0xf9, 0xf8, 0xe5, 0x45, 0xaf, 0x42, 0x9a, 0x50, 0x3b, 0x18, 0x8f, 0x0e, 0x7a, 0xd5, 0x67, 0x5f,
+	0xf1, 0x6e, 0x9c, 0x95, 0xd7, 0x26, 0xd4, 0xce, 0x5e, 0x9f, 0x71, 0xf5, 0x26, 0xd4, 0x2c, 0xae,
+	0x51, 0xe1, 0x36, 0xde, 0x5a, 0xc7, 0x17, 0xe3, 0x5e, 0x55, 0x98, 0x3b, 0x38, 0x3d, 0x3e, 0xeb,
+	0x69, 0xc3, 0x9f, 0xab, 0xd0, 0x78, 0xf5, 0xc6, 0xe6, 0xcf, 0x74, 0x62, 0x82, 0x66, 0x23, 0x23,
+	0xa2, 0x6e, 0xe7, 0x0f, 0xed, 0x81, 0x1c, 0x49, 0xc4, 0xab, 0xfd, 0x1e, 0xd9, 0x05, 0xed, 0x28,
+	0xd5, 0xc9, 0x5f, 0x61, 0x83, 0x8d, 0x4c, 0x96, 0xbc, 0x99, 0xf7, 0xc8, 0x5f, 0xa0, 0x95, 0x3d,
+	0x1a, 0xc8, 0x96, 0x5a, 0x2f, 0xbd, 0x5d, 0x06, 0xdb, 0x6b, 0x68, 0xb6, 0x77, 0x17, 0xea, 0xf2,
+	0xc5, 0x4d, 0x36, 0xb9, 0x4a, 0xe9, 0xf5, 0x5d, 0xf6, 0xe7, 0x39, 0xe8, 0xe2, 0xb1, 0x4b, 0x7a,
+	0x1c, 0x2d, 0xbe, 0xa0, 0x07, 0xdd, 0x0c, 0x11, 0x2f, 0x61, 0xf3, 0xde, 0x17, 0x15, 0xf2, 0x3b,
+	0xa8, 0xf1, 0xd7, 0x05, 0x11, 0xfe, 0x16, 0xde, 0x19, 0x83, 0x7c, 0xee, 0xe2, 0x7a, 0xc3, 0x7f,
+	0x56, 0xa1, 0xa1, 0x66, 0x0c, 0xf2, 0x35, 0x40, 0x3e, 0x58, 0x93, 0x6d, 0xd9, 0x1f, 0xd7, 0xe6,
+	0xf4, 0xc1, 0xce, 0x3a, 0x9c, 0xc5, 0x32, 0x04, 0xc8, 0xa7, 0x6a, 0x92, 0x86, 0x5c, 0x9e, 0xb2,
+	0xd7, 0x8e, 0x27, 0x0f, 0x41, 0x3b, 0x5f, 0x30, 0x92, 0xa3, 0xe5, 0xa0, 0xf7, 0x40, 0x3f, 0xc4,
+	0x2c, 0xe8, 0xe2, 0x68, 0x3d, 0xd8, 0x2c, 0x20, 0x99, 0x0b, 0x5f, 0x40, 0x43, 0x4d, 0xc5, 0x44,
+	0x94, 0xb5, 0xf2, 0x88, 0x3c, 0xe8, 0x70, 0x2c, 0x1b, 0x6d, 0x85, 0x03, 0xbf, 0x87, 0x1a, 0x1f,
+	0x71, 0x24, 0x4f, 0x85, 0x71, 0x69, 0xd0, 0xcb, 0x81, 0xd4, 0xfc, 0xf0, 0x5f, 0x1a, 0xe8, 0xa3,
+	0x69, 0xe0, 0x85, 0xe4, 0x18, 0xba, 0xe5, 0xf9, 0x8c, 0x3c, 0xe0, 0xfa, 0xb7, 0xce, 0xaf, 0x83,
+	0xc1, 0x6d, 0x4b, 0x99, 0xcf, 0x5f, 0x42, 0x43, 0xcd, 0x2a, 0xd2, 0xe7, 0xf2, 0x28, 0x33, 0xb8,
+	0x5f, 0xc2, 0xb2, 0x5d, 0x9f, 0x41, 0xdd, 0x16, 0x1d, 0x9c, 0xa4, 0xad, 0xba, 0xcc, 0xdc, 0x97,
+	0xd0, 0x50, 0x1d, 0x5e, 0x5a, 0x2d, 0x8f, 0x07, 0x83, 0xfb, 0x25, 0x2c, 0xb3, 0x6a, 0x42, 0xd3,
+	0x46, 0x26, 0x3b, 0x7d, 0xde, 0xf4, 0xca, 0x96, 0x0f, 0xa1, 0x53, 0x6a, 0x2e, 0xa4, 0xaf, 0x6c,
+	0xbd, 0xd7, 0x97, 0x07, 0x0f, 0x6e, 0x59, 0xc9, 0xce, 0xda, 0x03, 0x5d, 0xb6, 0x62, 0xc1, 0x74,
+	0xb1, 0xc5, 0x0c, 0x36, 0x0b, 0x48, 0xa6, 0xff, 0x02, 0x8c, 0x42, 0xdd, 0x22, 0x3b, 0xea, 0xe3,
+	0x5d, 0x2b, 0x7a, 0x83, 0x4f, 0xde, 0xc3, 0x53, 0x0b, 0x97, 0x75, 0xf1, 0xf7, 0xdc, 0x1f, 0xff,
+	0x33, 0x00, 0xd1, 0x9a, 0xb2, 0x3b, 0xaf, 0x13, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// KVStoreClient is the client API for KVStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type KVStoreClient interface {
+	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*Empty, error)
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	GetPrefix(ctx context.Context, in *GetPrefixRequest, opts ...grpc.CallOption) (*GetPrefixResponse, error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Empty, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (KVStore_WatchClient, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (KVStore_ScanClient, error)
+}
+
+type kVStoreClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewKVStoreClient(cc *grpc.ClientConn) KVStoreClient {
+	return &kVStoreClient{cc}
+}
+
+func (c *kVStoreClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
 	err := c.cc.Invoke(ctx, "/kv.KVStore/Set", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -323,104 +2520,834 @@ func (c *kVStoreClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.Ca
 	return out, nil
 }
 
-func (c *kVStoreClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
-	out := new(GetResponse)
-	err := c.cc.Invoke(ctx, "/kv.KVStore/Get", in, out, opts...)
+func (c *kVStoreClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, "/kv.KVStore/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) GetPrefix(ctx context.Context, in *GetPrefixRequest, opts ...grpc.CallOption) (*GetPrefixResponse, error) {
+	out := new(GetPrefixResponse)
+	err := c.cc.Invoke(ctx, "/kv.KVStore/GetPrefix", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/kv.KVStore/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (KVStore_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_KVStore_serviceDesc.Streams[0], "/kv.KVStore/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kVStoreWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KVStore_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type kVStoreWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVStoreWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *kVStoreClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (KVStore_ScanClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_KVStore_serviceDesc.Streams[1], "/kv.KVStore/Scan", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kVStoreScanClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KVStore_ScanClient interface {
+	Recv() (*Entry, error)
+	grpc.ClientStream
+}
+
+type kVStoreScanClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVStoreScanClient) Recv() (*Entry, error) {
+	m := new(Entry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// KVStoreServer is the server API for KVStore service.
+type KVStoreServer interface {
+	Set(context.Context, *SetRequest) (*Empty, error)
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	GetPrefix(context.Context, *GetPrefixRequest) (*GetPrefixResponse, error)
+	Delete(context.Context, *DeleteRequest) (*Empty, error)
+	Watch(*WatchRequest, KVStore_WatchServer) error
+	Scan(*ScanRequest, KVStore_ScanServer) error
+}
+
+func RegisterKVStoreServer(s *grpc.Server, srv KVStoreServer) {
+	s.RegisterService(&_KVStore_serviceDesc, srv)
+}
+
+func _KVStore_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.KVStore/Set",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Set(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.KVStore/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_GetPrefix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPrefixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).GetPrefix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.KVStore/GetPrefix",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).GetPrefix(ctx, req.(*GetPrefixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.KVStore/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVStoreServer).Watch(m, &kVStoreWatchServer{stream})
+}
+
+type KVStore_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type kVStoreWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVStoreWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _KVStore_Scan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVStoreServer).Scan(m, &kVStoreScanServer{stream})
+}
+
+type KVStore_ScanServer interface {
+	Send(*Entry) error
+	grpc.ServerStream
+}
+
+type kVStoreScanServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVStoreScanServer) Send(m *Entry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _KVStore_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.KVStore",
+	HandlerType: (*KVStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Set",
+			Handler:    _KVStore_Set_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _KVStore_Get_Handler,
+		},
+		{
+			MethodName: "GetPrefix",
+			Handler:    _KVStore_GetPrefix_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _KVStore_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _KVStore_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Scan",
+			Handler:       _KVStore_Scan_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "kvstore.proto",
+}
+
+// ReplicaClient is the client API for Replica service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ReplicaClient interface {
+	MerkleTree(ctx context.Context, in *MerkleTreeRequest, opts ...grpc.CallOption) (*MerkleTreeResponse, error)
+	GetBuckets(ctx context.Context, in *GetBucketsRequest, opts ...grpc.CallOption) (Replica_GetBucketsClient, error)
+	Put(ctx context.Context, in *Entry, opts ...grpc.CallOption) (*Empty, error)
+	Fetch(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*FetchResponse, error)
+	TailLog(ctx context.Context, in *TailLogRequest, opts ...grpc.CallOption) (Replica_TailLogClient, error)
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+}
+
+type replicaClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewReplicaClient(cc *grpc.ClientConn) ReplicaClient {
+	return &replicaClient{cc}
+}
+
+func (c *replicaClient) MerkleTree(ctx context.Context, in *MerkleTreeRequest, opts ...grpc.CallOption) (*MerkleTreeResponse, error) {
+	out := new(MerkleTreeResponse)
+	err := c.cc.Invoke(ctx, "/kv.Replica/MerkleTree", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *replicaClient) GetBuckets(ctx context.Context, in *GetBucketsRequest, opts ...grpc.CallOption) (Replica_GetBucketsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Replica_serviceDesc.Streams[0], "/kv.Replica/GetBuckets", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &replicaGetBucketsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Replica_GetBucketsClient interface {
+	Recv() (*Entry, error)
+	grpc.ClientStream
+}
+
+type replicaGetBucketsClient struct {
+	grpc.ClientStream
+}
+
+func (x *replicaGetBucketsClient) Recv() (*Entry, error) {
+	m := new(Entry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *replicaClient) Put(ctx context.Context, in *Entry, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/kv.Replica/Put", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *replicaClient) Fetch(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*FetchResponse, error) {
+	out := new(FetchResponse)
+	err := c.cc.Invoke(ctx, "/kv.Replica/Fetch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *replicaClient) TailLog(ctx context.Context, in *TailLogRequest, opts ...grpc.CallOption) (Replica_TailLogClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Replica_serviceDesc.Streams[1], "/kv.Replica/TailLog", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &replicaTailLogClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Replica_TailLogClient interface {
+	Recv() (*LogRecord, error)
+	grpc.ClientStream
+}
+
+type replicaTailLogClient struct {
+	grpc.ClientStream
+}
+
+func (x *replicaTailLogClient) Recv() (*LogRecord, error) {
+	m := new(LogRecord)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *replicaClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, "/kv.Replica/Ping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReplicaServer is the server API for Replica service.
+type ReplicaServer interface {
+	MerkleTree(context.Context, *MerkleTreeRequest) (*MerkleTreeResponse, error)
+	GetBuckets(*GetBucketsRequest, Replica_GetBucketsServer) error
+	Put(context.Context, *Entry) (*Empty, error)
+	Fetch(context.Context, *FetchRequest) (*FetchResponse, error)
+	TailLog(*TailLogRequest, Replica_TailLogServer) error
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+}
+
+func RegisterReplicaServer(s *grpc.Server, srv ReplicaServer) {
+	s.RegisterService(&_Replica_serviceDesc, srv)
+}
+
+func _Replica_MerkleTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleTreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicaServer).MerkleTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Replica/MerkleTree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServer).MerkleTree(ctx, req.(*MerkleTreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Replica_GetBuckets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetBucketsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplicaServer).GetBuckets(m, &replicaGetBucketsServer{stream})
+}
+
+type Replica_GetBucketsServer interface {
+	Send(*Entry) error
+	grpc.ServerStream
+}
+
+type replicaGetBucketsServer struct {
+	grpc.ServerStream
+}
+
+func (x *replicaGetBucketsServer) Send(m *Entry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Replica_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Entry)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicaServer).Put(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Replica/Put",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServer).Put(ctx, req.(*Entry))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Replica_Fetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicaServer).Fetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Replica/Fetch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServer).Fetch(ctx, req.(*FetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Replica_TailLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailLogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplicaServer).TailLog(m, &replicaTailLogServer{stream})
+}
+
+type Replica_TailLogServer interface {
+	Send(*LogRecord) error
+	grpc.ServerStream
+}
+
+type replicaTailLogServer struct {
+	grpc.ServerStream
+}
+
+func (x *replicaTailLogServer) Send(m *LogRecord) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Replica_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicaServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Replica/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Replica_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.Replica",
+	HandlerType: (*ReplicaServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MerkleTree",
+			Handler:    _Replica_MerkleTree_Handler,
+		},
+		{
+			MethodName: "Put",
+			Handler:    _Replica_Put_Handler,
+		},
+		{
+			MethodName: "Fetch",
+			Handler:    _Replica_Fetch_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _Replica_Ping_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetBuckets",
+			Handler:       _Replica_GetBuckets_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "TailLog",
+			Handler:       _Replica_TailLog_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "kvstore.proto",
+}
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminClient interface {
+	VerifyReplicas(ctx context.Context, in *VerifyReplicasRequest, opts ...grpc.CallOption) (*VerifyReplicasResponse, error)
+	Members(ctx context.Context, in *MembersRequest, opts ...grpc.CallOption) (*MembersResponse, error)
+	SetAcl(ctx context.Context, in *Acl, opts ...grpc.CallOption) (*Empty, error)
+	GetAcls(ctx context.Context, in *GetAclsRequest, opts ...grpc.CallOption) (*GetAclsResponse, error)
+	SetQuota(ctx context.Context, in *Quota, opts ...grpc.CallOption) (*Empty, error)
+	GetNamespaces(ctx context.Context, in *GetNamespacesRequest, opts ...grpc.CallOption) (*GetNamespacesResponse, error)
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) VerifyReplicas(ctx context.Context, in *VerifyReplicasRequest, opts ...grpc.CallOption) (*VerifyReplicasResponse, error) {
+	out := new(VerifyReplicasResponse)
+	err := c.cc.Invoke(ctx, "/kv.Admin/VerifyReplicas", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *kVStoreClient) GetPrefix(ctx context.Context, in *GetPrefixRequest, opts ...grpc.CallOption) (*GetPrefixResponse, error) {
-	out := new(GetPrefixResponse)
-	err := c.cc.Invoke(ctx, "/kv.KVStore/GetPrefix", in, out, opts...)
+func (c *adminClient) Members(ctx context.Context, in *MembersRequest, opts ...grpc.CallOption) (*MembersResponse, error) {
+	out := new(MembersResponse)
+	err := c.cc.Invoke(ctx, "/kv.Admin/Members", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// KVStoreServer is the server API for KVStore service.
-type KVStoreServer interface {
-	Set(context.Context, *SetRequest) (*Empty, error)
-	Get(context.Context, *GetRequest) (*GetResponse, error)
-	GetPrefix(context.Context, *GetPrefixRequest) (*GetPrefixResponse, error)
+func (c *adminClient) SetAcl(ctx context.Context, in *Acl, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/kv.Admin/SetAcl", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func RegisterKVStoreServer(s *grpc.Server, srv KVStoreServer) {
-	s.RegisterService(&_KVStore_serviceDesc, srv)
+func (c *adminClient) GetAcls(ctx context.Context, in *GetAclsRequest, opts ...grpc.CallOption) (*GetAclsResponse, error) {
+	out := new(GetAclsResponse)
+	err := c.cc.Invoke(ctx, "/kv.Admin/GetAcls", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func _KVStore_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SetRequest)
+func (c *adminClient) SetQuota(ctx context.Context, in *Quota, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/kv.Admin/SetQuota", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetNamespaces(ctx context.Context, in *GetNamespacesRequest, opts ...grpc.CallOption) (*GetNamespacesResponse, error) {
+	out := new(GetNamespacesResponse)
+	err := c.cc.Invoke(ctx, "/kv.Admin/GetNamespaces", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, "/kv.Admin/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/kv.Admin/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+type AdminServer interface {
+	VerifyReplicas(context.Context, *VerifyReplicasRequest) (*VerifyReplicasResponse, error)
+	Members(context.Context, *MembersRequest) (*MembersResponse, error)
+	SetAcl(context.Context, *Acl) (*Empty, error)
+	GetAcls(context.Context, *GetAclsRequest) (*GetAclsResponse, error)
+	SetQuota(context.Context, *Quota) (*Empty, error)
+	GetNamespaces(context.Context, *GetNamespacesRequest) (*GetNamespacesResponse, error)
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_VerifyReplicas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyReplicasRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KVStoreServer).Set(ctx, in)
+		return srv.(AdminServer).VerifyReplicas(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/kv.KVStore/Set",
+		FullMethod: "/kv.Admin/VerifyReplicas",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KVStoreServer).Set(ctx, req.(*SetRequest))
+		return srv.(AdminServer).VerifyReplicas(ctx, req.(*VerifyReplicasRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _KVStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRequest)
+func _Admin_Members_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MembersRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KVStoreServer).Get(ctx, in)
+		return srv.(AdminServer).Members(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/kv.KVStore/Get",
+		FullMethod: "/kv.Admin/Members",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KVStoreServer).Get(ctx, req.(*GetRequest))
+		return srv.(AdminServer).Members(ctx, req.(*MembersRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _KVStore_GetPrefix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetPrefixRequest)
+func _Admin_SetAcl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Acl)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KVStoreServer).GetPrefix(ctx, in)
+		return srv.(AdminServer).SetAcl(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/kv.KVStore/GetPrefix",
+		FullMethod: "/kv.Admin/SetAcl",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KVStoreServer).GetPrefix(ctx, req.(*GetPrefixRequest))
+		return srv.(AdminServer).SetAcl(ctx, req.(*Acl))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _KVStore_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "kv.KVStore",
-	HandlerType: (*KVStoreServer)(nil),
+func _Admin_GetAcls_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAclsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetAcls(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Admin/GetAcls",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetAcls(ctx, req.(*GetAclsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SetQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Quota)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Admin/SetQuota",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetQuota(ctx, req.(*Quota))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetNamespaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNamespacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetNamespaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Admin/GetNamespaces",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetNamespaces(ctx, req.(*GetNamespacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Admin/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Admin/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.Admin",
+	HandlerType: (*AdminServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "Set",
-			Handler:    _KVStore_Set_Handler,
+			MethodName: "VerifyReplicas",
+			Handler:    _Admin_VerifyReplicas_Handler,
 		},
 		{
-			MethodName: "Get",
-			Handler:    _KVStore_Get_Handler,
+			MethodName: "Members",
+			Handler:    _Admin_Members_Handler,
 		},
 		{
-			MethodName: "GetPrefix",
-			Handler:    _KVStore_GetPrefix_Handler,
+			MethodName: "SetAcl",
+			Handler:    _Admin_SetAcl_Handler,
+		},
+		{
+			MethodName: "GetAcls",
+			Handler:    _Admin_GetAcls_Handler,
+		},
+		{
+			MethodName: "SetQuota",
+			Handler:    _Admin_SetQuota_Handler,
+		},
+		{
+			MethodName: "GetNamespaces",
+			Handler:    _Admin_GetNamespaces_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _Admin_Stats_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _Admin_SetLogLevel_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
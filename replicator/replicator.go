@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ss87021456/gRPC-KVStore/tlsconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// keep alive param
+var kacp = keepalive.ClientParameters{
+	Time:                10 * time.Second, // send pings every 10 seconds if there is no activity
+	Timeout:             time.Second,      // wait 1 second for ping ack before considering the connection dead
+	PermitWithoutStream: true,             // send pings even without active streams
+}
+
+var source = "localhost:6000"
+var target = "localhost:7000"
+var prefixes = ""
+var checkpointDir = "."
+var bidirectional = false
+var statInterval = 10
+var maxMsgSize = 1024 * 1024 * 16
+var useTLS = false
+var tlsCA = ""
+var tlsCert = ""
+var tlsKey = ""
+var token = ""
+
+// transport is plaintext, or TLS with the -tls flags
+var transport = grpc.WithInsecure()
+
+func dial(addr string) *grpc.ClientConn {
+	options := []grpc.DialOption{transport}
+	if token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+	conn, err := grpc.Dial(addr, append(options,
+		grpc.WithKeepaliveParams(kacp),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize), grpc.MaxCallSendMsgSize(maxMsgSize)))...)
+	if err != nil {
+		log.Fatalf("failed to connect to server %s: %s", addr, err)
+	}
+	return conn
+}
+
+// tokenCredentials sends -token with every call
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+func main() {
+	flag.StringVar(&source, "source", source, "a server of the cluster to replicate from")
+	flag.StringVar(&target, "target", target, "a server of the standby cluster to replicate to")
+	flag.StringVar(&prefixes, "prefix", prefixes, "comma separated key prefixes to replicate, all keys if empty")
+	flag.StringVar(&checkpointDir, "checkpoint_dir", checkpointDir, "directory keeping the resume position of each direction")
+	flag.BoolVar(&bidirectional, "bidirectional", bidirectional, "also replicate from target to source, the later write of a key wins")
+	flag.IntVar(&statInterval, "stat_interval", statInterval, "seconds between replication lag reports")
+	flag.BoolVar(&useTLS, "tls", useTLS, "dial both clusters over TLS, implied by -tls_ca and -tls_cert")
+	flag.StringVar(&tlsCA, "tls_ca", tlsCA, "PEM CA certificates verifying the servers, the system roots if empty")
+	flag.StringVar(&tlsCert, "tls_cert", tlsCert, "PEM client certificate for servers requiring one, reloaded when the file changes")
+	flag.StringVar(&tlsKey, "tls_key", tlsKey, "PEM private key of -tls_cert")
+	flag.StringVar(&token, "token", token, "bearer token for servers started with -auth_tokens, it needs admin on every key")
+	flag.Parse()
+	if useTLS || tlsCA != "" || tlsCert != "" {
+		certs, err := tlsconfig.NewReloader(tlsconfig.Files{CertFile: tlsCert, KeyFile: tlsKey, CAFile: tlsCA}, tlsconfig.DefaultReloadInterval)
+		if err != nil {
+			log.Fatalf("failed to load the TLS certificates: %s", err)
+		}
+		defer certs.Close()
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(certs.ClientConfig()))
+	}
+
+	var filters []string
+	if prefixes != "" {
+		filters = strings.Split(prefixes, ",")
+	}
+	sourceConn, targetConn := dial(source), dial(target)
+	defer sourceConn.Close()
+	defer targetConn.Close()
+
+	links := []*link{newLink(source, target, sourceConn, targetConn, filters)}
+	if bidirectional {
+		links = append(links, newLink(target, source, targetConn, sourceConn, filters))
+	}
+	for _, l := range links {
+		go l.run()
+	}
+	for range time.Tick(time.Duration(statInterval) * time.Second) {
+		for _, l := range links {
+			l.report()
+		}
+	}
+}
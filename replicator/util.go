@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+)
+
+const retryInterval = 5 * time.Second
+const checkpointInterval = time.Second
+
+// Checkpoint is where a link resumes tailing the log of its source
+type Checkpoint struct {
+	Epoch, Offset int64
+}
+
+// link replicates one direction, from the log of one server to another server
+type link struct {
+	from, to   string
+	source     pb.ReplicaClient
+	target     pb.ReplicaClient
+	prefixes   []string
+	checkpoint string
+
+	lock      sync.Mutex
+	pos       Checkpoint
+	applied   int64
+	lagBytes  int64
+	lagTime   time.Duration
+	connected bool
+}
+
+func newLink(from, to string, sourceConn, targetConn *grpc.ClientConn, prefixes []string) *link {
+	name := fmt.Sprintf("checkpoint-%s-to-%s.json", from, to)
+	name = strings.NewReplacer(":", "_", "/", "_").Replace(name)
+	return &link{
+		from:       from,
+		to:         to,
+		source:     pb.NewReplicaClient(sourceConn),
+		target:     pb.NewReplicaClient(targetConn),
+		prefixes:   prefixes,
+		checkpoint: filepath.Join(checkpointDir, name),
+	}
+}
+
+func loadCheckpoint(filename string) (Checkpoint, error) {
+	var pos Checkpoint
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return pos, nil
+	}
+	if err != nil {
+		return pos, err
+	}
+	err = json.Unmarshal(data, &pos)
+	return pos, err
+}
+
+// saveCheckpoint replaces the checkpoint file atomically, a crash leaves either
+// the old or the new position behind
+func saveCheckpoint(filename string, pos Checkpoint) error {
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	tmpName := filename + ".tmp"
+	file, err := os.OpenFile(tmpName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+	return os.Rename(tmpName, filename)
+}
+
+func (l *link) run() {
+	for {
+		start := time.Now()
+		err := l.tail()
+		l.lock.Lock()
+		l.connected = false
+		l.lock.Unlock()
+		log.Printf("replication %s -> %s interrupted: %v", l.from, l.to, err)
+		// servers close long-lived connections (see MaxConnectionAge), resume
+		// right away unless the source keeps failing
+		if time.Since(start) < retryInterval {
+			time.Sleep(retryInterval)
+		}
+	}
+}
+
+// tail follows the log of the source from the checkpoint and applies every
+// record to the target. Records carry the version of the original write, so
+// the target keeps whichever write of a key came last and replaying records
+// after a crash is harmless.
+func (l *link) tail() error {
+	pos, err := loadCheckpoint(l.checkpoint)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := l.source.TailLog(ctx, &pb.TailLogRequest{Epoch: pos.Epoch, Offset: pos.Offset, Prefixes: l.prefixes})
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	l.connected = true
+	l.lock.Unlock()
+
+	lastSave := time.Now()
+	for {
+		record, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if e := record.GetEntry(); e != nil {
+			putCtx, putCancel := context.WithTimeout(ctx, 10*time.Second)
+			_, err := l.target.Put(putCtx, e)
+			putCancel()
+			if err != nil {
+				return err
+			}
+		}
+
+		pos = Checkpoint{Epoch: record.GetEpoch(), Offset: record.GetOffset()}
+		l.lock.Lock()
+		l.pos = pos
+		l.lagBytes = record.GetLogSize() - record.GetOffset()
+		if record.GetEntry() == nil || l.lagBytes <= 0 {
+			l.lagTime = 0
+		} else {
+			l.lagTime = time.Since(time.Unix(0, record.GetEntry().GetVersion()))
+		}
+		if record.GetEntry() != nil {
+			l.applied++
+		}
+		l.lock.Unlock()
+
+		if time.Since(lastSave) >= checkpointInterval || record.GetEntry() == nil {
+			if err := saveCheckpoint(l.checkpoint, pos); err != nil {
+				return err
+			}
+			lastSave = time.Now()
+		}
+	}
+}
+
+func (l *link) report() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	log.Printf("replication %s -> %s: connected %v, applied %d records, lag %s (%d bytes behind), position epoch %d offset %d",
+		l.from, l.to, l.connected, l.applied, l.lagTime, l.lagBytes, l.pos.Epoch, l.pos.Offset)
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the key space is split into 2^merkleDepth buckets by key hash, each bucket
+// is a leaf of the merkle tree
+const merkleDepth = 10
+const maxMerkleDepth = 16
+
+// merkleTree keeps its nodes as a heap, nodes[0] is the root and the children
+// of nodes[i] are nodes[2i+1] and nodes[2i+2]
+type merkleTree struct {
+	depth uint32
+	nodes [][]byte
+}
+
+// antiEntropy periodically compares this server with its peers and pulls the
+// keys whose newer version lives on a peer. Keys written with a quorum are
+// only compared between two of their owners, so they stay on their n servers.
+type antiEntropy struct {
+	s        *ServerMgr
+	interval time.Duration
+}
+
+func newAntiEntropy(s *ServerMgr, interval time.Duration) *antiEntropy {
+	return &antiEntropy{s: s, interval: interval}
+}
+
+func bucketOf(key string, depth uint32) uint32 {
+	if depth == 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return uint32(h.Sum64() >> (64 - depth))
+}
+
+func entryDigest(key string, entry cacheEntry) []byte {
+	h := fnv.New128a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(entry.Value))
+	binary.Write(h, binary.BigEndian, entry.Version)
+	binary.Write(h, binary.BigEndian, entry.Deleted)
+	return h.Sum(nil)
+}
+
+// buildMerkleTree covers the keys this server shares with peer, all of them
+// if peer is empty
+func buildMerkleTree(s *ServerMgr, depth uint32, peer string) *merkleTree {
+	leaves := 1 << depth
+	t := &merkleTree{depth: depth, nodes: make([][]byte, 2*leaves-1)}
+	first := leaves - 1
+	for i := first; i < len(t.nodes); i++ {
+		t.nodes[i] = make([]byte, 16)
+	}
+	// a leaf is the xor of its entry digests, so the iteration order does not matter
+	for m := range s.inMemoryCache.IterBuffered() {
+		if !s.heldBy(m.Key, m.Val.(cacheEntry), s.peers.self, peer) {
+			continue
+		}
+		leaf := t.nodes[first+int(bucketOf(m.Key, depth))]
+		for i, b := range entryDigest(m.Key, m.Val.(cacheEntry)) {
+			leaf[i] ^= b
+		}
+	}
+	for i := first - 1; i >= 0; i-- {
+		h := fnv.New128a()
+		h.Write(t.nodes[2*i+1])
+		h.Write(t.nodes[2*i+2])
+		t.nodes[i] = h.Sum(nil)
+	}
+	return t
+}
+
+// diffBuckets walks both trees from the root and returns the buckets whose
+// leaves differ, skipping every subtree whose hashes already match. Trees of
+// another shape cannot be compared.
+func diffBuckets(a, b *merkleTree) ([]uint32, error) {
+	if a.depth != b.depth || len(a.nodes) != len(b.nodes) {
+		return nil, fmt.Errorf("merkle tree of depth %d with %d nodes, want depth %d with %d nodes", b.depth, len(b.nodes), a.depth, len(a.nodes))
+	}
+	diffs := []uint32{}
+	first := len(a.nodes) / 2
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if bytes.Equal(a.nodes[i], b.nodes[i]) {
+			continue
+		}
+		if i >= first {
+			diffs = append(diffs, uint32(i-first))
+			continue
+		}
+		stack = append(stack, 2*i+1, 2*i+2)
+	}
+	return diffs, nil
+}
+
+func (s *ServerMgr) MerkleTree(ctx context.Context, req *pb.MerkleTreeRequest) (*pb.MerkleTreeResponse, error) {
+	depth := req.GetDepth()
+	if depth > maxMerkleDepth {
+		return &pb.MerkleTreeResponse{}, status.Errorf(codes.InvalidArgument, "merkle depth %d exceeds %d", depth, maxMerkleDepth)
+	}
+	t := buildMerkleTree(s, depth, req.GetFrom())
+	return &pb.MerkleTreeResponse{Depth: t.depth, Nodes: t.nodes}, nil
+}
+
+func (s *ServerMgr) GetBuckets(req *pb.GetBucketsRequest, stream pb.Replica_GetBucketsServer) error {
+	depth := req.GetDepth()
+	if depth > maxMerkleDepth {
+		return status.Errorf(codes.InvalidArgument, "merkle depth %d exceeds %d", depth, maxMerkleDepth)
+	}
+	wanted := make(map[uint32]bool)
+	for _, b := range req.GetBuckets() {
+		wanted[b] = true
+	}
+	for m := range s.inMemoryCache.IterBuffered() {
+		if !wanted[bucketOf(m.Key, depth)] || !s.heldBy(m.Key, m.Val.(cacheEntry), s.peers.self, req.GetFrom()) {
+			continue
+		}
+		if err := stream.Send(toEntry(m.Key, m.Val.(cacheEntry))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ServerMgr) VerifyReplicas(ctx context.Context, req *pb.VerifyReplicasRequest) (*pb.VerifyReplicasResponse, error) {
+	if s.replicas == nil {
+		return &pb.VerifyReplicasResponse{}, status.Errorf(codes.FailedPrecondition, "no peers configured")
+	}
+	peers := req.GetPeers()
+	if len(peers) == 0 {
+		peers = s.peers.addrs
+	}
+	res := &pb.VerifyReplicasResponse{}
+	for _, peer := range peers {
+		report := &pb.PeerReport{Peer: peer}
+		diffs, err := s.replicas.compare(ctx, peer)
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.InSync = len(diffs) == 0
+			report.Diffs = diffs
+		}
+		res.Reports = append(res.Reports, report)
+	}
+	return res, nil
+}
+
+// compare returns every key whose version differs between this server and the peer
+func (ae *antiEntropy) compare(ctx context.Context, peer string) ([]*pb.KeyDiff, error) {
+	remote, local, err := ae.divergentEntries(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	diffs := []*pb.KeyDiff{}
+	for key, r := range remote {
+		if l, ok := local[key]; !ok || l != r {
+			diffs = append(diffs, &pb.KeyDiff{Key: key, LocalVersion: local[key].Version, RemoteVersion: r.Version})
+		}
+	}
+	for key, l := range local {
+		if _, ok := remote[key]; !ok {
+			diffs = append(diffs, &pb.KeyDiff{Key: key, LocalVersion: l.Version})
+		}
+	}
+	return diffs, nil
+}
+
+// divergentEntries compares merkle trees with the peer and returns the entries
+// of the buckets that differ, as held by the peer and by this server, leaving
+// out the keys written with a quorum that are not owned by both
+func (ae *antiEntropy) divergentEntries(ctx context.Context, peer string) (map[string]cacheEntry, map[string]cacheEntry, error) {
+	client, err := ae.s.peers.replicaClient(peer)
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := client.MerkleTree(ctx, &pb.MerkleTreeRequest{Depth: merkleDepth, From: ae.s.peers.self})
+	if err != nil {
+		return nil, nil, err
+	}
+	buckets, err := diffBuckets(buildMerkleTree(ae.s, merkleDepth, peer), &merkleTree{depth: res.GetDepth(), nodes: res.GetNodes()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", peer, err)
+	}
+	remote := make(map[string]cacheEntry)
+	local := make(map[string]cacheEntry)
+	if len(buckets) == 0 {
+		return remote, local, nil
+	}
+
+	stream, err := client.GetBuckets(ctx, &pb.GetBucketsRequest{Depth: merkleDepth, Buckets: buckets, From: ae.s.peers.self})
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		remote[e.GetKey()] = fromEntry(e)
+	}
+
+	wanted := make(map[uint32]bool)
+	for _, b := range buckets {
+		wanted[b] = true
+	}
+	for m := range ae.s.inMemoryCache.IterBuffered() {
+		if wanted[bucketOf(m.Key, merkleDepth)] && ae.s.heldBy(m.Key, m.Val.(cacheEntry), ae.s.peers.self, peer) {
+			local[m.Key] = m.Val.(cacheEntry)
+		}
+	}
+	return remote, local, nil
+}
+
+// repair pulls every key the peer holds a newer version of, equal versions
+// settled by newerEntry. Keys that are newer here get pushed when the peer
+// runs its own repair against this server.
+func (ae *antiEntropy) repair(ctx context.Context, peer string) (int, error) {
+	remote, local, err := ae.divergentEntries(ctx, peer)
+	if err != nil {
+		return 0, err
+	}
+	repaired := 0
+	for key, r := range remote {
+		if !ae.s.heldBy(key, r, ae.s.peers.self, peer) {
+			continue
+		}
+		if l, ok := local[key]; ok && !newerEntry(r, l) {
+			continue
+		}
+		applied, err := applyEntry(ctx, ae.s, key, r)
+		if err != nil {
+			return repaired, err
+		}
+		if applied {
+			repaired++
+		}
+	}
+	return repaired, nil
+}
+
+func (ae *antiEntropy) repairWith(peer string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ae.interval)
+	defer cancel()
+	repaired, err := ae.repair(ctx, peer)
+	if err != nil {
+		slog.Warn("anti-entropy failed", "peer", peer, "err", err)
+		return
+	}
+	if repaired > 0 {
+		slog.Info("anti-entropy repaired keys", "peer", peer, "keys", repaired)
+	}
+}
+
+// run repairs from every live peer periodically, and from a peer as soon as it
+// comes back from the dead, since it may have taken writes while cut off
+func (ae *antiEntropy) run(events <-chan memberEvent) {
+	ticker := time.NewTicker(ae.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, peer := range ae.s.peers.addrs {
+				if ae.s.members.isAlive(peer) {
+					ae.repairWith(peer)
+				}
+			}
+		case ev := <-events:
+			if ev.from == pb.MemberState_DEAD && ev.to == pb.MemberState_ALIVE {
+				ae.repairWith(ev.addr)
+			}
+		}
+	}
+}
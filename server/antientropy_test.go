@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// quorumServer returns server a of the cluster a, b, c holding a key written
+// with a quorum of 2 that a owns, the other owner of the key and the server
+// that does not own it
+func quorumServer(t *testing.T) (s *ServerMgr, key, owner, other string) {
+	t.Helper()
+	s = NewServerMgr("normal", "a:1")
+	s.peers = newPeerSet("a:1", []string{"b:1", "c:1"})
+	for i := 0; ; i++ {
+		key = fmt.Sprintf("key%d", i)
+		owners := preferenceList(s.peers.nodes(), key, 2)
+		switch {
+		case owners[0] == "a:1":
+			owner = owners[1]
+		case owners[1] == "a:1":
+			owner = owners[0]
+		default:
+			continue
+		}
+		other = "b:1"
+		if owner == "b:1" {
+			other = "c:1"
+		}
+		setHelper(s, key, cacheEntry{Value: "v", Version: 1, Replicas: 2})
+		return s, key, owner, other
+	}
+}
+
+func TestQuorumKeysStayOnTheirOwners(t *testing.T) {
+	s, key, owner, other := quorumServer(t)
+	empty := buildMerkleTree(NewServerMgr("normal", "x:1"), merkleDepth, "")
+	if !bytes.Equal(buildMerkleTree(s, merkleDepth, other).nodes[0], empty.nodes[0]) {
+		t.Errorf("merkle tree for %s covers %q, which it does not own", other, key)
+	}
+	if bytes.Equal(buildMerkleTree(s, merkleDepth, owner).nodes[0], empty.nodes[0]) {
+		t.Errorf("merkle tree for %s leaves out %q, which both own", owner, key)
+	}
+	if !s.heldBy(key, cacheEntry{}, other) {
+		t.Error("key written without a quorum not held by every server")
+	}
+}
+
+func TestLogRecordKeepsReplicas(t *testing.T) {
+	for _, entry := range []cacheEntry{{Value: "v", Version: 7}, {Value: "v", Version: 7, Replicas: 3}, {Version: 8, Deleted: true, Replicas: 2}} {
+		line := formatLogRecord("k", entry)
+		key, got, ok := parseLogRecord(line)
+		if !ok || key != "k" || got != entry {
+			t.Errorf("%q parsed as %q %+v %v, want %+v", line, key, got, ok, entry)
+		}
+		if _, _, ok := parseLogRecord(line[:len(line)-3]); ok {
+			t.Errorf("%q cut short still parses", line)
+		}
+	}
+}
@@ -4,27 +4,49 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cmap "github.com/orcaman/concurrent-map"
 	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type ServerMgr struct {
+	revision      int64            // applied revision, bumped on every write; keep first for 64-bit atomic alignment
+	lastWrite     int64            // latest version applied, peers compare theirs with it to tell the lag of this server
+	walSize       int64            // bytes in the write-ahead log
+	walEpoch      int64            // when the log was last compacted, log offsets are only valid within an epoch
+	opsCount      [numCounts]int64 // calls handled by kind, indexed by countSet and friends
+	walSignal     chan struct{}    // closed and replaced on every log append to wake up log tailers
+	stopping      chan struct{}    // closed when the server shuts down, ending the streams that never end on their own
 	inMemoryCache cmap.ConcurrentMap
-	lastSnapTime  int64
+	lastSnapTime  int64 // unix time of the snapshot last written or loaded
 	logLock       sync.Mutex
 	logFile       *os.File
-	countLock     sync.Mutex
-	opsCount      []int
 	mode          string
+	peers         *peerSet
+	replicas      *antiEntropy
+	hints         *hintStore
+	members       *membership
+	watchers      *watchHub
+	namespaces    *namespaces
+	auth          *authenticator // nil when anyone may do anything
+	keys          *keyring       // nil when the data at rest is plaintext
+	limits        limits
+	startTime     time.Time
+	recoveryTime  time.Duration // to load history.log at start
 }
 
 type SharedCache []*SingleCache
@@ -36,86 +58,279 @@ type SingleCache struct {
 
 type JsonData struct {
 	Key, Value string
+	Version    int64
+	Deleted    bool
+	Replicas   uint32 `json:",omitempty"`
 }
 
-func NewServerMgr(mode string) *ServerMgr {
-	return &ServerMgr{inMemoryCache: cmap.New(), opsCount: make([]int, 3), mode: mode}
+func (m JsonData) entry() cacheEntry {
+	return cacheEntry{Value: m.Value, Version: m.Version, Deleted: m.Deleted, Replicas: m.Replicas}
+}
+
+// cacheEntry is what inMemoryCache holds for every key
+type cacheEntry struct {
+	Value    string
+	Version  int64  // unix nano time of the write, the later version wins
+	Deleted  bool   // tombstone, kept so replicas holding an older version do not bring the key back
+	Replicas uint32 // n of the quorum the key was written with, 0 when every server holds it
+}
+
+func NewServerMgr(mode string, self string) *ServerMgr {
+	s := &ServerMgr{inMemoryCache: cmap.New(), mode: mode, peers: newPeerSet(self, nil),
+		walSignal: make(chan struct{}), stopping: make(chan struct{}), watchers: newWatchHub(), startTime: time.Now()}
+	s.namespaces = newNamespaces(s)
+	return s
 }
 
 func (s *ServerMgr) Get(ctx context.Context, getReq *pb.GetRequest) (*pb.GetResponse, error) {
-	key := getReq.GetKey()
+	atomic.AddInt64(&s.opsCount[countGet], 1)
+	ns, err := s.namespaces.enter(ctx, getReq.GetNamespace(), opGet)
+	if err != nil {
+		return &pb.GetResponse{}, err
+	}
+	key := ns.prefix + getReq.GetKey()
 	// log.Printf("Get key: %s", key)
-	val, err := getHelper(s, key)
-	if s.mode == "test" {
-		s.countLock.Lock()
-		s.opsCount[0]++
-		s.countLock.Unlock()
+	st, err := checkReadOptions(ctx, s, getReq.GetReadOptions())
+	if err != nil {
+		return &pb.GetResponse{}, err
+	}
+	if st.forwardTo != "" {
+		res := &pb.GetResponse{}
+		err = forwardRead(ctx, s, st, func(ctx context.Context, peer pb.KVStoreClient) (err error) {
+			res, err = peer.Get(ctx, getReq)
+			return err
+		})
+		return res, err
 	}
-	return &pb.GetResponse{Value: val}, err
+	var val string
+	if q := getReq.GetQuorum(); q.GetN() > 0 {
+		val, err = quorumGetHelper(s, key, q)
+	} else {
+		val, err = getHelper(s, key)
+	}
+	return &pb.GetResponse{Value: val, Revision: st.revision, StalenessMs: st.stalenessMs}, err
 
 }
 
 func (s *ServerMgr) Set(ctx context.Context, setReq *pb.SetRequest) (*pb.Empty, error) {
+	atomic.AddInt64(&s.opsCount[countSet], 1)
+	ns, err := s.namespaces.enter(ctx, setReq.GetNamespace(), opSet)
+	if err != nil {
+		return &pb.Empty{}, err
+	}
 	key, value := setReq.GetKey(), setReq.GetValue()
 	// log.Printf("Set key: %s, value: %s", key, value)
-	err := writeAheadLog(s, key, value)
+	release, err := ns.reserveWrite(s, key, value)
 	if err != nil {
-		return &pb.Empty{}, nil
+		return &pb.Empty{}, err
 	}
-	setHelper(s, key, value)
-	if s.mode == "test" {
-		s.countLock.Lock()
-		s.opsCount[1]++
-		s.countLock.Unlock()
+	defer release()
+	err = writeHelper(ctx, s, ns.prefix+key, cacheEntry{Value: value}, setReq.GetQuorum())
+	if err != nil {
+		return &pb.Empty{}, err
 	}
 	return &pb.Empty{}, nil
 }
 
 func (s *ServerMgr) GetPrefix(ctx context.Context, getPrefixReq *pb.GetPrefixRequest) (*pb.GetPrefixResponse, error) {
-	res := prefixHelper(s, getPrefixReq.GetKey())
-	// log.Printf("Get prefix: %s", getPrefixReq.GetKey())
-	if s.mode == "test" {
-		s.countLock.Lock()
-		s.opsCount[2]++
-		s.countLock.Unlock()
+	atomic.AddInt64(&s.opsCount[countGetPrefix], 1)
+	ns, err := s.namespaces.enter(ctx, getPrefixReq.GetNamespace(), opRead)
+	if err != nil {
+		return &pb.GetPrefixResponse{}, err
+	}
+	st, err := checkReadOptions(ctx, s, getPrefixReq.GetReadOptions())
+	if err != nil {
+		return &pb.GetPrefixResponse{}, err
 	}
+	if st.forwardTo != "" {
+		res := &pb.GetPrefixResponse{}
+		err = forwardRead(ctx, s, st, func(ctx context.Context, peer pb.KVStoreClient) (err error) {
+			res, err = peer.GetPrefix(ctx, getPrefixReq)
+			return err
+		})
+		return res, err
+	}
+	res := prefixHelper(ctx, s, ns.prefix+getPrefixReq.GetKey())
+	// log.Printf("Get prefix: %s", getPrefixReq.GetKey())
 	if len(res) > 0 {
-		return &pb.GetPrefixResponse{Values: res}, nil
+		return &pb.GetPrefixResponse{Values: res, Revision: st.revision, StalenessMs: st.stalenessMs}, nil
 	}
-	return &pb.GetPrefixResponse{}, fmt.Errorf("No specific prefix %s found", getPrefixReq.GetKey())
+	return &pb.GetPrefixResponse{Revision: st.revision, StalenessMs: st.stalenessMs}, status.Errorf(codes.NotFound, "No specific prefix %s found", getPrefixReq.GetKey())
 }
 
-func (s *ServerMgr) SnapShot(filename string) {
-	oFile, _ := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY, os.ModePerm)
-	// clean up the file, but need to use a more efficient way
-	oFile.Truncate(0)
-	oFile.Seek(0, 0)
-	defer oFile.Close()
+func (s *ServerMgr) Scan(scanReq *pb.ScanRequest, stream pb.KVStore_ScanServer) error {
+	atomic.AddInt64(&s.opsCount[countScan], 1)
+	ns, err := s.namespaces.enter(stream.Context(), scanReq.GetNamespace(), opRead)
+	if err != nil {
+		return err
+	}
+	st, err := checkReadOptions(stream.Context(), s, scanReq.GetReadOptions())
+	if err != nil {
+		return err
+	}
+	if st.forwardTo != "" {
+		return forwardRead(stream.Context(), s, st, func(ctx context.Context, peer pb.KVStoreClient) error {
+			peerStream, err := peer.Scan(ctx, scanReq)
+			if err != nil {
+				return err
+			}
+			for {
+				entry, err := peerStream.Recv()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := stream.Send(entry); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	prefix := ns.prefix + scanReq.GetPrefix()
+	_, span := tracing.Start(stream.Context(), "cache.prefix_scan")
+	defer span.End()
+	sent := 0
+	for item := range s.inMemoryCache.IterBuffered() {
+		entry := item.Val.(cacheEntry)
+		if entry.Deleted || !visible(item.Key, prefix) {
+			continue
+		}
+		if err := stream.Send(toEntry(item.Key[len(ns.prefix):], entry)); err != nil {
+			return err
+		}
+		sent++
+	}
+	span.SetAttributes(attribute.Int("cache.matches", sent))
+	return nil
+}
+
+func (s *ServerMgr) Delete(ctx context.Context, delReq *pb.DeleteRequest) (*pb.Empty, error) {
+	atomic.AddInt64(&s.opsCount[countDelete], 1)
+	ns, err := s.namespaces.enter(ctx, delReq.GetNamespace(), opDelete)
+	if err != nil {
+		return &pb.Empty{}, err
+	}
+	err = writeHelper(ctx, s, ns.prefix+delReq.GetKey(), cacheEntry{Deleted: true}, delReq.GetQuorum())
+	return &pb.Empty{}, err
+}
+
+// SnapShot writes the cache to filename, through a temporary file synced to
+// disk and renamed so a crash never leaves half a snapshot
+func (s *ServerMgr) SnapShot(filename string) error {
+	defer func(start time.Time) { snapshotDuration.Observe(time.Since(start).Seconds()) }(time.Now())
+	tmp := filename + ".tmp"
+	oFile, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp) // fails once renamed
 	encoder := json.NewEncoder(oFile)
-	encoder.Encode(time.Now().Unix())
-	err := encoder.Encode(s.makeData())
+	now := time.Now().Unix()
+	encoder.Encode(now)
+	if s.keys != nil {
+		err = s.writeSealedBlocks(oFile)
+	} else {
+		err = encoder.Encode(s.makeData())
+	}
+	if err == nil {
+		err = oFile.Sync()
+	}
+	if cerr := oFile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
 	if err != nil {
-		log.Printf("fail to write to file %s", err)
+		return fmt.Errorf("snapshot %s: %v", filename, err)
+	}
+	atomic.StoreInt64(&s.lastSnapTime, now)
+	return nil
+}
+
+// snapshotBlock is how many keys a sealed snapshot block holds
+const snapshotBlock = 1024
+
+// writeSealedBlocks writes the keys as lines of sealed JSON arrays, the
+// encrypted form of the snapshot
+func (s *ServerMgr) writeSealedBlocks(w io.Writer) error {
+	block := make([]JsonData, 0, snapshotBlock)
+	flush := func() error {
+		data, err := json.Marshal(block)
+		if err != nil {
+			return err
+		}
+		block = block[:0]
+		_, err = io.WriteString(w, s.keys.seal(string(data))+"\n")
+		return err
+	}
+	for m := range s.inMemoryCache.IterBuffered() {
+		entry := m.Val.(cacheEntry)
+		block = append(block, JsonData{Key: m.Key, Value: entry.Value, Version: entry.Version, Deleted: entry.Deleted, Replicas: entry.Replicas})
+		if len(block) == snapshotBlock {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(block) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+// readSealedBlocks loads the blocks written by writeSealedBlocks
+func (s *ServerMgr) readSealedBlocks(r *bufio.Reader, filename string) error {
+	for n := 1; ; n++ {
+		line, err := r.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			plain, err := s.keys.open(line)
+			if err != nil {
+				return fmt.Errorf("%s block %d: %v", filename, n, err)
+			}
+			var block []JsonData
+			if err := json.Unmarshal([]byte(plain), &block); err != nil {
+				return fmt.Errorf("%s block %d: %v", filename, n, err)
+			}
+			for _, m := range block {
+				setHelper(s, m.Key, m.entry())
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 	}
 }
 
 func (s *ServerMgr) makeData() interface{} {
 	datas := []map[string]interface{}{}
 	for m := range s.inMemoryCache.Iter() {
+		entry := m.Val.(cacheEntry)
 		data := map[string]interface{}{
-			"Key":   m.Key,
-			"Value": m.Val,
+			"Key":     m.Key,
+			"Value":   entry.Value,
+			"Version": entry.Version,
+			"Deleted": entry.Deleted,
+		}
+		if entry.Replicas > 0 {
+			data["Replicas"] = entry.Replicas
 		}
 		datas = append(datas, data)
 	}
 	return datas
 }
 
+// LoadFromSnapshot fills the cache from a snapshot, a damaged one is an error
+// left to the caller
 func (s *ServerMgr) LoadFromSnapshot(filename string) error {
-	log.Printf("Initializing cache from file: %s\n", filename)
+	slog.Info("loading snapshot", "file", filename)
 	iFile, err := os.OpenFile(filename, os.O_RDONLY, os.ModePerm)
 	if err != nil {
-		log.Println("Need to create new data storage...", err)
 		return err
 	}
 	defer iFile.Close()
@@ -124,32 +339,43 @@ func (s *ServerMgr) LoadFromSnapshot(filename string) error {
 	iFile.Read(timestampByte)
 	timestamp, err := strconv.Atoi(fmt.Sprintf("%s", timestampByte))
 	if err != nil {
-		log.Printf("Parse timestamp encounter err: %s", err)
+		slog.Warn("snapshot has no valid timestamp", "file", filename, "err", err)
 	}
 	s.lastSnapTime = int64(timestamp)
 	iFile.Seek(10, 0)
-	decoder := json.NewDecoder(iFile)
+	reader := bufio.NewReader(iFile)
+	for {
+		if b, err := reader.Peek(1); err != nil || (b[0] != ' ' && b[0] != '\n') {
+			break
+		}
+		reader.ReadByte()
+	}
+	if b, err := reader.Peek(1); err == nil && b[0] == sealedMark {
+		if err := s.readSealedBlocks(reader, filename); err != nil {
+			return err
+		}
+		slog.Info("snapshot loaded", "file", filename, "keys", s.inMemoryCache.Count())
+		return nil
+	}
+	decoder := json.NewDecoder(reader)
 	// Read the array open bracket
 	if _, err := decoder.Token(); err != nil {
-		log.Fatal("Encounter wrong json format data1...", err)
-		return err
+		return fmt.Errorf("%s: no JSON array: %v", filename, err)
 	}
 	// while the array contains values
 	for decoder.More() {
 		var m JsonData
 		err := decoder.Decode(&m)
 		if err != nil {
-			log.Fatal("Encounter wrong json format data3...", err)
-			return err
+			return fmt.Errorf("%s: bad entry: %v", filename, err)
 		}
-		setHelper(s, m.Key, m.Value)
+		setHelper(s, m.Key, m.entry())
 	}
 	// read closing bracket
 	if _, err := decoder.Token(); err != nil {
-		log.Fatal("Encounter wrong json format data...", err)
-		return err
+		return fmt.Errorf("%s: unterminated JSON array: %v", filename, err)
 	}
-	log.Printf("Finish initializing cache from file: %s\n", filename)
+	slog.Info("snapshot loaded", "file", filename, "keys", s.inMemoryCache.Count())
 	return nil
 }
 
@@ -159,42 +385,88 @@ func (s *ServerMgr) LoadFromHistoryLog(filename string) error {
 
 	file, err := os.Open(filename)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	scanner := bufio.NewScanner(file)
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 1024*1024*5)
-	for scanner.Scan() {
-		arr := strings.Split(scanner.Text(), ",")
-		if arr[3] == "done" { // checksum-like detection
-			setHelper(s, arr[1], arr[2]) // arr layout -> [timestamp, key, value]
+	reader := bufio.NewReader(file)
+	stale := 0            // records not sealed with the current key, sealed again by the compaction
+	var offset, end int64 // bytes read, and the end of the last record replayed
+	var unread error      // a line that cannot be opened or parsed, fatal unless the last one
+	for line := 1; ; line++ {
+		text, rerr := reader.ReadString('\n')
+		if rerr != nil && rerr != io.EOF {
+			file.Close()
+			return fmt.Errorf("%s: %v", filename, rerr)
+		}
+		offset += int64(len(text))
+		if strings.TrimSpace(text) != "" {
+			key, entry, ok, err := s.readLogLine(text)
+			if err == nil && !ok {
+				err = errors.New("not a complete log record")
+			}
+			if err != nil {
+				if unread == nil {
+					unread = fmt.Errorf("%s:%d: %v", filename, line, err)
+				}
+			} else {
+				if unread != nil {
+					file.Close()
+					return unread
+				}
+				setHelper(s, key, entry)
+				if s.keys != nil && !strings.HasPrefix(text, string(sealedMark)+s.keys.current+":") {
+					stale++
+				}
+				end = offset
+			}
+		}
+		if rerr == io.EOF {
+			break
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
-	}
-	log.Printf("done recovery from dataset %s with size %d", filename, s.inMemoryCache.Count())
 	file.Close()
+	if end < offset {
+		// the tail of an append cut short by a crash, dropped so that the
+		// next appends do not land on the same line
+		slog.Warn("dropping torn records at the end of the log", "file", filename, "bytes", offset-end, "err", unread)
+		if err := os.Truncate(filename, end); err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	slog.Info("history replayed", "file", filename, "keys", s.inMemoryCache.Count())
 
-	// log compaction
-	newFile, err := os.OpenFile("new.log", os.O_CREATE|os.O_WRONLY, os.ModePerm)
-	newFile.Truncate(0)
-	newFile.Seek(0, 0)
+	// log compaction, skipped on failure since the log replayed is still whole
+	defer func(start time.Time) { compactionDuration.Observe(time.Since(start).Seconds()) }(time.Now())
+	newFile, err := os.OpenFile("new.log", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
 	if err != nil {
-		log.Println("Failed to create ntemporary file: new.log", err)
+		slog.Error("compaction skipped", "err", err)
+		return nil
 	}
 	for m := range s.inMemoryCache.Iter() {
-		outStr := fmt.Sprintf("%v,%s,%s,done\n", time.Now().Unix(), m.Key, m.Val)
-		if _, err := newFile.WriteString(outStr); err != nil {
-			log.Println(err)
+		outStr := s.logLine(m.Key, m.Val.(cacheEntry))
+		if _, err = newFile.WriteString(outStr); err != nil {
+			break
 		}
 	}
-	newFile.Close()
+	if err == nil {
+		err = newFile.Sync() // before the rename, or a crash may leave a short log in place of the whole one
+	}
+	if cerr := newFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		slog.Error("compaction skipped", "err", err)
+		os.Remove("new.log")
+		return nil
+	}
 
-	c := exec.Command("mv", "new.log", "history.log")
-	if err := c.Run(); err != nil {
-		fmt.Println("Exec mv new.log failed: ", err)
+	if err := os.Rename("new.log", "history.log"); err != nil {
+		slog.Error("compaction failed to replace history.log", "err", err)
+		os.Remove("new.log")
+	} else if err := syncDir(); err != nil {
+		slog.Error("compaction failed to sync the log directory", "err", err)
+	} else if stale > 0 {
+		slog.Info("compaction encrypted history.log", "key", s.keys.current, "stale_records", stale)
 	}
 
 	return nil
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// keys of the server itself live under reservedPrefix, the KVStore service
+// neither shows nor changes them but they are logged and replicated as usual
+const reservedPrefix = "__kvstore/"
+
+// aclPrefix + principal holds the grants of the principal
+const aclPrefix = reservedPrefix + "acl/"
+
+func reservedKey(key string) bool {
+	return strings.HasPrefix(key, reservedPrefix)
+}
+
+type principalKey struct{}
+
+// principalFrom returns who made the call, "" when authentication is off
+func principalFrom(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// authenticator identifies callers by a bearer token or the common name of
+// their verified client certificate, and checks their grants
+type authenticator struct {
+	s      *ServerMgr
+	tokens map[string]string // token to principal
+	mtls   bool
+	admins map[string]bool // principals with admin on every key whatever the ACLs
+
+	lock   sync.Mutex
+	parsed map[string]parsedAcl // decoded ACL values by principal
+}
+
+type parsedAcl struct {
+	version int64
+	grants  []*pb.Grant
+}
+
+func newAuthenticator(s *ServerMgr, tokens map[string]string, mtls bool, admins []string) *authenticator {
+	a := &authenticator{s: s, tokens: tokens, mtls: mtls, admins: make(map[string]bool), parsed: make(map[string]parsedAcl)}
+	for _, admin := range admins {
+		if admin != "" {
+			a.admins[admin] = true
+		}
+	}
+	return a
+}
+
+// loadTokens reads a file of principal and token pairs, one per line
+func loadTokens(filename string) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want a principal and a token", filename, line)
+		}
+		tokens[fields[1]] = fields[0]
+	}
+	return tokens, scanner.Err()
+}
+
+func (a *authenticator) authenticate(ctx context.Context) (string, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 && a.tokens != nil {
+			token := strings.TrimPrefix(values[0], "Bearer ")
+			if principal, ok := a.tokens[token]; ok {
+				return principal, nil
+			}
+			return "", status.Errorf(codes.Unauthenticated, "invalid token")
+		}
+	}
+	if a.mtls {
+		if p, ok := peer.FromContext(ctx); ok {
+			if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+				if cn := info.State.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+					return cn, nil
+				}
+			}
+		}
+	}
+	return "", status.Errorf(codes.Unauthenticated, "missing credentials")
+}
+
+// grants returns the grants of the principal, read from the store
+func (a *authenticator) grants(principal string) []*pb.Grant {
+	if a.admins[principal] {
+		return []*pb.Grant{{Prefix: "", Permission: pb.Permission_ADMIN}}
+	}
+	tmp, ok := a.s.inMemoryCache.Get(aclPrefix + principal)
+	if !ok {
+		return nil
+	}
+	entry := tmp.(cacheEntry)
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if p, ok := a.parsed[principal]; ok && p.version == entry.Version {
+		return p.grants
+	}
+	var grants []*pb.Grant
+	if !entry.Deleted {
+		grants = decodeGrants(entry.Value)
+	}
+	a.parsed[principal] = parsedAcl{version: entry.Version, grants: grants}
+	return grants
+}
+
+// allowed reports whether the principal holds perm on every key of the
+// namespace starting with prefix. Admin on every key of the default namespace
+// covers every namespace.
+func (a *authenticator) allowed(principal string, perm pb.Permission, namespace string, prefix string) bool {
+	for _, g := range a.grants(principal) {
+		superuser := g.GetNamespace() == "" && g.GetPrefix() == "" && g.GetPermission() == pb.Permission_ADMIN
+		if g.GetPermission() >= perm && strings.HasPrefix(prefix, g.GetPrefix()) && (g.GetNamespace() == namespace || superuser) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *authenticator) require(principal string, perm pb.Permission, namespace string, prefix string) error {
+	if a.allowed(principal, perm, namespace, prefix) {
+		return nil
+	}
+	if namespace != "" {
+		prefix = namespace + ":" + prefix
+	}
+	return status.Errorf(codes.PermissionDenied, "%s has no %s permission on %q", principal, strings.ToLower(perm.String()), prefix)
+}
+
+// checkRequest authorizes the request of a call, a nil authenticator only
+// keeps the reserved keys out of reach
+func checkRequest(a *authenticator, ctx context.Context, method string, req interface{}) error {
+	var perm pb.Permission
+	var namespace string
+	var prefixes []string
+	switch r := req.(type) {
+	case *pb.GetRequest:
+		perm, namespace, prefixes = pb.Permission_READ, r.GetNamespace(), []string{r.GetKey()}
+	case *pb.GetPrefixRequest:
+		perm, namespace, prefixes = pb.Permission_READ, r.GetNamespace(), []string{r.GetKey()}
+	case *pb.ScanRequest:
+		perm, namespace, prefixes = pb.Permission_READ, r.GetNamespace(), []string{r.GetPrefix()}
+	case *pb.WatchRequest:
+		perm, namespace, prefixes = pb.Permission_READ, r.GetNamespace(), r.GetPrefixes()
+		if len(prefixes) == 0 {
+			prefixes = []string{""}
+		}
+	case *pb.SetRequest:
+		perm, namespace, prefixes = pb.Permission_WRITE, r.GetNamespace(), []string{r.GetKey()}
+	case *pb.DeleteRequest:
+		perm, namespace, prefixes = pb.Permission_WRITE, r.GetNamespace(), []string{r.GetKey()}
+	default:
+		if a == nil || method == "/kv.Admin/SetAcl" || method == "/kv.Admin/GetAcls" {
+			return nil // the ACL calls check the grants themselves
+		}
+		// replication and the other admin calls
+		return a.require(principalFrom(ctx), pb.Permission_ADMIN, "", "")
+	}
+	for _, prefix := range prefixes {
+		if reservedKey(prefix) {
+			return status.Errorf(codes.PermissionDenied, "keys starting with %s are reserved", reservedPrefix)
+		}
+		if a != nil {
+			if err := a.require(principalFrom(ctx), perm, namespace, prefix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exempt calls need no credentials
+func exempt(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.Health/")
+}
+
+func (a *authenticator) context(ctx context.Context, method string) (context.Context, error) {
+	if a == nil || exempt(method) {
+		return ctx, nil
+	}
+	principal, err := a.authenticate(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, principalKey{}, principal), nil
+}
+
+// unaryInterceptor authenticates and authorizes unary calls, a may be nil
+func unaryInterceptor(a *authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.context(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		if !exempt(info.FullMethod) {
+			if err := checkRequest(a, ctx, info.FullMethod, req); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStream checks the request of a server streaming call as it is received
+type authStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	a      *authenticator
+	method string
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkRequest(s.a, s.ctx, s.method, m)
+}
+
+// streamInterceptor authenticates and authorizes streaming calls, a may be nil
+func streamInterceptor(a *authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if exempt(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, err := a.context(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx, a: a, method: info.FullMethod})
+	}
+}
+
+// encodeGrants lays out grants as space separated permission=prefix pairs,
+// permission@namespace=prefix outside the default namespace, escaped so the
+// value never holds a comma or a newline of the log
+func encodeGrants(grants []*pb.Grant) string {
+	parts := make([]string, 0, len(grants))
+	for _, g := range grants {
+		perm := strings.ToLower(g.GetPermission().String())
+		if g.GetNamespace() != "" {
+			perm += "@" + g.GetNamespace()
+		}
+		parts = append(parts, perm+"="+url.QueryEscape(g.GetPrefix()))
+	}
+	return strings.Join(parts, " ")
+}
+
+func decodeGrants(value string) []*pb.Grant {
+	var grants []*pb.Grant
+	for _, part := range strings.Fields(value) {
+		i := strings.IndexByte(part, '=')
+		if i < 0 {
+			continue
+		}
+		name, namespace := part[:i], ""
+		if j := strings.IndexByte(name, '@'); j >= 0 {
+			name, namespace = name[:j], name[j+1:]
+		}
+		prefix, err := url.QueryUnescape(part[i+1:])
+		perm, ok := pb.Permission_value[strings.ToUpper(name)]
+		if err != nil || !ok {
+			continue
+		}
+		grants = append(grants, &pb.Grant{Prefix: prefix, Permission: pb.Permission(perm), Namespace: namespace})
+	}
+	return grants
+}
+
+// SetAcl replaces the grants of a principal. The caller needs admin on the
+// prefixes of the grants it gives and of the ones it takes away.
+func (s *ServerMgr) SetAcl(ctx context.Context, acl *pb.Acl) (*pb.Empty, error) {
+	principal := acl.GetPrincipal()
+	if principal == "" || strings.ContainsAny(principal, ",\n") {
+		return &pb.Empty{}, status.Errorf(codes.InvalidArgument, "invalid principal %q", principal)
+	}
+	var grants []*pb.Grant
+	for _, g := range acl.GetGrants() {
+		if _, ok := pb.Permission_name[int32(g.GetPermission())]; !ok {
+			return &pb.Empty{}, status.Errorf(codes.InvalidArgument, "unknown permission %v", g.GetPermission())
+		}
+		if !validNamespace(g.GetNamespace()) {
+			return &pb.Empty{}, status.Errorf(codes.InvalidArgument, "invalid namespace %q", g.GetNamespace())
+		}
+		if g.GetPermission() != pb.Permission_NONE {
+			grants = append(grants, g)
+		}
+	}
+	if s.auth != nil {
+		caller := principalFrom(ctx)
+		current := s.auth.grants(principal)
+		if s.auth.admins[principal] {
+			current = nil // fixed by -auth_admins, only the stored grants change
+		}
+		for _, g := range append(current, grants...) {
+			if err := s.auth.require(caller, pb.Permission_ADMIN, g.GetNamespace(), g.GetPrefix()); err != nil {
+				return &pb.Empty{}, err
+			}
+		}
+	}
+	entry := cacheEntry{Value: encodeGrants(grants)}
+	if len(grants) == 0 {
+		entry = cacheEntry{Deleted: true}
+	}
+	return &pb.Empty{}, writeHelper(ctx, s, aclPrefix+principal, entry, nil)
+}
+
+// GetAcls returns the stored grants of one or every principal. Admins on all
+// keys see every principal, others only themselves.
+func (s *ServerMgr) GetAcls(ctx context.Context, req *pb.GetAclsRequest) (*pb.GetAclsResponse, error) {
+	principal := req.GetPrincipal()
+	if s.auth != nil {
+		caller := principalFrom(ctx)
+		if principal != caller {
+			if err := s.auth.require(caller, pb.Permission_ADMIN, "", ""); err != nil {
+				return &pb.GetAclsResponse{}, err
+			}
+		}
+	}
+	var acls []*pb.Acl
+	for item := range s.inMemoryCache.IterBuffered() {
+		entry := item.Val.(cacheEntry)
+		name := strings.TrimPrefix(item.Key, aclPrefix)
+		if entry.Deleted || name == item.Key || principal != "" && name != principal {
+			continue
+		}
+		acls = append(acls, &pb.Acl{Principal: name, Grants: decodeGrants(entry.Value)})
+	}
+	sort.Slice(acls, func(i, j int) bool { return acls[i].GetPrincipal() < acls[j].GetPrincipal() })
+	return &pb.GetAclsResponse{Acls: acls}, nil
+}
+
+// tokenCredentials sends the bearer token of this server to its peers
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
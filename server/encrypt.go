@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// sealedMark starts a line sealed by a keyring, a plaintext log record starts
+// with its version and a plaintext snapshot block with [
+const sealedMark = '@'
+
+var keyID = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// keyring holds the AES-GCM keys of the data at rest by id. The current key
+// seals new lines, the others still open what they sealed before a rotation
+// until compaction seals it again with the current one.
+type keyring struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// loadKeyring reads the keys of the -encryption_key_file or of the variable
+// named by -encryption_key_env, both id:hexkey entries separated by spaces,
+// commas or newlines. The last entry is the current key.
+func loadKeyring(filename string, env string) (*keyring, error) {
+	var spec, source string
+	switch {
+	case filename != "" && env != "":
+		return nil, errors.New("-encryption_key_file and -encryption_key_env exclude each other")
+	case filename != "":
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		spec, source = string(data), filename
+	case env != "":
+		spec, source = os.Getenv(env), "$"+env
+	default:
+		return nil, nil
+	}
+	k := &keyring{aeads: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' || r == '\r' }) {
+		i := strings.IndexByte(entry, ':')
+		if i < 0 || !keyID.MatchString(entry[:i]) {
+			return nil, fmt.Errorf("%s: want id:hexkey, got %q", source, entry)
+		}
+		raw, err := hex.DecodeString(entry[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %s: %v", source, entry[:i], err)
+		}
+		block, err := aes.NewCipher(raw) // 16, 24 or 32 bytes for AES-128, 192 or 256
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %s: %v", source, entry[:i], err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[entry[:i]], k.current = aead, entry[:i]
+	}
+	if k.current == "" {
+		return nil, fmt.Errorf("%s: no key", source)
+	}
+	return k, nil
+}
+
+// seal encrypts a line as @id:base64(nonce|ciphertext), the key id also being
+// authenticated. A nil keyring leaves the line as it is.
+func (k *keyring) seal(line string) string {
+	if k == nil {
+		return line
+	}
+	aead := k.aeads[k.current]
+	buf := make([]byte, aead.NonceSize(), aead.NonceSize()+len(line)+aead.Overhead())
+	if _, err := rand.Read(buf); err != nil {
+		panic(err) // the system has no randomness left, nothing is safe to write
+	}
+	buf = aead.Seal(buf, buf, []byte(line), []byte(k.current))
+	return string(sealedMark) + k.current + ":" + base64.StdEncoding.EncodeToString(buf)
+}
+
+// open decrypts a sealed line, other lines are returned as they are so data
+// written before encryption was turned on stays readable
+func (k *keyring) open(line string) (string, error) {
+	if len(line) == 0 || line[0] != sealedMark {
+		return line, nil
+	}
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return "", errors.New("sealed line without key id")
+	}
+	id := line[1:i]
+	if k == nil {
+		return "", fmt.Errorf("line sealed with key %s but encryption is off", id)
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("line sealed with unknown key %s", id)
+	}
+	buf, err := base64.StdEncoding.DecodeString(strings.TrimRight(line[i+1:], "\r\n"))
+	if err != nil || len(buf) < aead.NonceSize() {
+		return "", fmt.Errorf("corrupt line sealed with key %s", id)
+	}
+	plain, err := aead.Open(nil, buf[:aead.NonceSize()], buf[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("line sealed with key %s: %v", id, err)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// inTempDir runs the test in a directory of its own, where the server keeps
+// history.log
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestLoadFromHistoryLogRejectsDamagedRecords(t *testing.T) {
+	inTempDir(t)
+	log := formatLogRecord("a", cacheEntry{Value: "1", Version: 1}) +
+		"2 \"b\" \"2\" dome\n" +
+		formatLogRecord("c", cacheEntry{Value: "3", Version: 3})
+	if err := os.WriteFile("history.log", []byte(log), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err := NewServerMgr("normal", "").LoadFromHistoryLog("history.log")
+	if err == nil || !strings.Contains(err.Error(), "history.log:2") {
+		t.Fatalf("damaged record followed by others loaded with %v, want an error naming history.log:2", err)
+	}
+	if data, _ := os.ReadFile("history.log"); string(data) != log {
+		t.Error("log changed after a failed load")
+	}
+}
+
+func TestLoadFromHistoryLogDropsTornTail(t *testing.T) {
+	inTempDir(t)
+	whole := formatLogRecord("a", cacheEntry{Value: "1", Version: 1}) + formatLogRecord("b", cacheEntry{Value: "2", Version: 2})
+	torn := formatLogRecord("c", cacheEntry{Value: "3", Version: 3})
+	if err := os.WriteFile("history.log", []byte(whole+torn[:len(torn)-4]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServerMgr("normal", "")
+	if err := s.LoadFromHistoryLog("history.log"); err != nil {
+		t.Fatal(err)
+	}
+	if s.inMemoryCache.Count() != 2 || s.inMemoryCache.Has("c") {
+		t.Errorf("loaded %v, want a and b", s.inMemoryCache.Keys())
+	}
+	if _, err := os.Stat("new.log"); !os.IsNotExist(err) {
+		t.Error("new.log left behind by the compaction")
+	}
+	again := NewServerMgr("normal", "")
+	if err := again.LoadFromHistoryLog("history.log"); err != nil || again.inMemoryCache.Count() != 2 {
+		t.Errorf("compacted log loads %d keys, err %v", again.inMemoryCache.Count(), err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata of a call holding its request id, sent by
+// kvclient and echoed in the response header
+const requestIDKey = "x-request-id"
+
+// logLevel is the level of the JSON logger, changed at runtime by SetLogLevel
+var logLevel = new(slog.LevelVar)
+
+// setupLogging makes a JSON logger on stderr the default one, the standard
+// log package included so no line escapes it
+func setupLogging(level string) error {
+	l, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	logLevel.Set(l)
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+	return nil
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return l, fmt.Errorf("invalid log level %q, want debug, info, warn or error", level)
+	}
+	return l, nil
+}
+
+func levelName(l slog.Level) string {
+	return strings.ToLower(l.String())
+}
+
+type loggerKey struct{}
+
+// logger returns the logger of the call of ctx, tagged with its request id
+// and method, or the default logger outside of calls
+func logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// requestContext picks the request id of the caller, or makes one, and
+// returns a context holding the logger of the call
+func requestContext(ctx context.Context, fullMethod string) (context.Context, string) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 && len(values[0]) <= 128 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = newRequestID()
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id))
+	l := slog.Default().With("request_id", id, "method", fullMethod)
+	return context.WithValue(ctx, loggerKey{}, l), id
+}
+
+// logCall reports the end of a call, at debug level unless the server failed
+func logCall(ctx context.Context, start time.Time, err error) {
+	level := slog.LevelDebug
+	switch status.Code(err) {
+	case codes.Internal, codes.DataLoss, codes.Unknown:
+		level = slog.LevelError
+	}
+	attrs := []any{"code", status.Code(err).String(), "duration_ms", float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		attrs = append(attrs, "err", status.Convert(err).Message())
+	}
+	logger(ctx).Log(ctx, level, "call done", attrs...)
+}
+
+// requestUnaryInterceptor gives every unary call a request id and logger, it
+// runs right after tracing so that the span gets the id too
+func requestUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx, id := requestContext(ctx, info.FullMethod)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	res, err := handler(ctx, req)
+	logCall(ctx, start, err)
+	return res, err
+}
+
+// requestStream hands the context holding the logger to the handler
+type requestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestStream) Context() context.Context {
+	return s.ctx
+}
+
+func requestStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, id := requestContext(ss.Context(), info.FullMethod)
+	ss.SetHeader(metadata.Pairs(requestIDKey, id))
+	err := handler(srv, &requestStream{ServerStream: ss, ctx: ctx})
+	logCall(ctx, start, err)
+	return err
+}
+
+// SetLogLevel changes the level of the logger until the server stops
+func (s *ServerMgr) SetLogLevel(ctx context.Context, req *pb.SetLogLevelRequest) (*pb.SetLogLevelResponse, error) {
+	previous := logLevel.Level()
+	if req.GetLevel() != "" {
+		l, err := parseLevel(req.GetLevel())
+		if err != nil {
+			return &pb.SetLogLevelResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		logLevel.Set(l)
+		logger(ctx).Info("log level changed", "from", levelName(previous), "to", levelName(l))
+	}
+	return &pb.SetLogLevelResponse{Previous: levelName(previous), Level: levelName(logLevel.Level())}, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+)
+
+// member is what this server knows about one of its peers
+type member struct {
+	state     pb.MemberState
+	lastSeen  time.Time
+	since     time.Time
+	lastWrite int64 // latest version the peer applied as of lastSeen
+}
+
+// memberEvent is published whenever a peer changes state
+type memberEvent struct {
+	addr     string
+	from, to pb.MemberState
+}
+
+// membership tracks which peers are alive by sending each of them a heartbeat,
+// with the timing the server already uses to keep client connections alive: a
+// heartbeat every kasp.Time, waiting kasp.Timeout for the answer. A peer that
+// misses a heartbeat is suspect, and dead once it has not answered for
+// kasp.MaxConnectionIdle.
+type membership struct {
+	s           *ServerMgr
+	interval    time.Duration
+	timeout     time.Duration
+	deadAfter   time.Duration
+	lock        sync.Mutex
+	members     map[string]*member
+	subscribers []chan memberEvent
+}
+
+func newMembership(s *ServerMgr) *membership {
+	m := &membership{
+		s:         s,
+		interval:  kasp.Time,
+		timeout:   kasp.Timeout,
+		deadAfter: kasp.MaxConnectionIdle,
+		members:   make(map[string]*member),
+	}
+	// peers start out alive, so requests are not turned away before the first heartbeat
+	now := time.Now()
+	for _, addr := range s.peers.addrs {
+		m.members[addr] = &member{state: pb.MemberState_ALIVE, lastSeen: now, since: now}
+	}
+	return m
+}
+
+// subscribe returns a channel receiving every following state change. Events
+// are dropped for a subscriber that falls behind, subscribers are expected to
+// also catch up periodically.
+func (m *membership) subscribe() <-chan memberEvent {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	ch := make(chan memberEvent, 64)
+	m.subscribers = append(m.subscribers, ch)
+	return ch
+}
+
+// isAlive reports whether requests should still be sent to the node. Suspect
+// nodes are still tried, this server itself is always alive.
+func (m *membership) isAlive(addr string) bool {
+	if m == nil || addr == m.s.peers.self {
+		return true
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if mem, ok := m.members[addr]; ok {
+		return mem.state != pb.MemberState_DEAD
+	}
+	return true
+}
+
+// transition must be called with m.lock held
+func (m *membership) transition(addr string, mem *member, to pb.MemberState, now time.Time) {
+	if mem.state == to {
+		return
+	}
+	ev := memberEvent{addr: addr, from: mem.state, to: to}
+	mem.state, mem.since = to, now
+	slog.Info("member state changed", "member", addr, "state", to, "was", ev.from)
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// heard marks the peer alive, after a heartbeat from or to it went through,
+// and records how far the peer got
+func (m *membership) heard(addr string, lastWrite int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	mem, ok := m.members[addr]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	mem.lastSeen = now
+	if lastWrite > mem.lastWrite {
+		mem.lastWrite = lastWrite
+	}
+	m.transition(addr, mem, pb.MemberState_ALIVE, now)
+}
+
+// freshest returns the live peer that applied the latest write, as of the
+// last heartbeats, and the version of that write. Writes the peers applied
+// since their last heartbeat are not known yet.
+func (m *membership) freshest() (string, int64) {
+	if m == nil {
+		return "", 0
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	freshest, lastWrite := "", int64(0)
+	for addr, mem := range m.members {
+		if mem.state != pb.MemberState_DEAD && mem.lastWrite > lastWrite {
+			freshest, lastWrite = addr, mem.lastWrite
+		}
+	}
+	return freshest, lastWrite
+}
+
+func (m *membership) missed(addr string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	mem, ok := m.members[addr]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if now.Sub(mem.lastSeen) >= m.deadAfter {
+		m.transition(addr, mem, pb.MemberState_DEAD, now)
+	} else if mem.state == pb.MemberState_ALIVE {
+		m.transition(addr, mem, pb.MemberState_SUSPECT, now)
+	}
+}
+
+func (m *membership) ping(addr string) {
+	client, err := m.s.peers.replicaClient(addr)
+	res := &pb.PingResponse{}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		res, err = client.Ping(ctx, &pb.PingRequest{From: m.s.peers.self, LastWrite: atomic.LoadInt64(&m.s.lastWrite)})
+		cancel()
+	}
+	if err != nil {
+		m.missed(addr)
+		return
+	}
+	m.heard(addr, res.GetLastWrite())
+}
+
+func (m *membership) run() {
+	for range time.Tick(m.interval) {
+		var wg sync.WaitGroup
+		for _, addr := range m.s.peers.addrs {
+			wg.Add(1)
+			go func(addr string) {
+				defer wg.Done()
+				m.ping(addr)
+			}(addr)
+		}
+		wg.Wait()
+	}
+}
+
+func (s *ServerMgr) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	if s.members != nil {
+		s.members.heard(req.GetFrom(), req.GetLastWrite())
+	}
+	return &pb.PingResponse{LastWrite: atomic.LoadInt64(&s.lastWrite)}, nil
+}
+
+func (s *ServerMgr) Members(ctx context.Context, req *pb.MembersRequest) (*pb.MembersResponse, error) {
+	now := time.Now().UnixNano()
+	res := &pb.MembersResponse{Members: []*pb.Member{{Addr: s.peers.self, State: pb.MemberState_ALIVE, LastSeen: now, Since: s.startTime.UnixNano()}}}
+	if s.members == nil {
+		return res, nil
+	}
+	s.members.lock.Lock()
+	for addr, mem := range s.members.members {
+		res.Members = append(res.Members, &pb.Member{Addr: addr, State: mem.state, LastSeen: mem.lastSeen.UnixNano(), Since: mem.since.UnixNano()})
+	}
+	s.members.lock.Unlock()
+	sort.Slice(res.Members[1:], func(i, j int) bool { return res.Members[i+1].Addr < res.Members[j+1].Addr })
+	return res, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// opsCount indices, the calls handled by kind
+const (
+	countSet = iota
+	countGet
+	countGetPrefix
+	countDelete
+	countScan
+	countWatch
+	numCounts
+)
+
+// latencyBuckets go from 50µs, a cached get, to 10s, a large scan
+var latencyBuckets = []float64{.00005, .0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kvstore_rpc_requests_total",
+		Help: "Calls handled, by service and method.",
+	}, []string{"service", "method"})
+	rpcErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kvstore_rpc_errors_total",
+		Help: "Calls that failed, by service, method and status code.",
+	}, []string{"service", "method", "code"})
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kvstore_rpc_duration_seconds",
+		Help:    "Time to handle a call, until the end of the stream for streaming calls.",
+		Buckets: latencyBuckets,
+	}, []string{"service", "method"})
+	walFsyncDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kvstore_wal_fsync_duration_seconds",
+		Help:    "Time to fsync history.log after an append.",
+		Buckets: latencyBuckets,
+	})
+	snapshotDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kvstore_snapshot_duration_seconds",
+		Help:    "Time to write a snapshot.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	})
+	compactionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kvstore_compaction_duration_seconds",
+		Help:    "Time to compact history.log.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	})
+)
+
+// registerMetrics adds the metrics of the server to the default registry,
+// next to the Go runtime and process metrics
+func registerMetrics(s *ServerMgr) {
+	prometheus.MustRegister(rpcRequests, rpcErrors, rpcDuration, walFsyncDuration, snapshotDuration, compactionDuration)
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "kvstore_wal_size_bytes",
+		Help: "Size of history.log.",
+	}, func() float64 { return float64(atomic.LoadInt64(&s.walSize)) }))
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "kvstore_keys",
+		Help: "Keys held in memory, tombstones and the keys of the server included.",
+	}, func() float64 { return float64(s.inMemoryCache.Count()) }))
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "kvstore_memory_bytes",
+		Help: "Bytes of allocated heap objects.",
+	}, func() float64 {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return float64(m.HeapAlloc)
+	}))
+}
+
+// serveMetrics serves /metrics on addr until the process ends
+func serveMetrics(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.Serve(lis, mux); err != nil {
+			slog.Error("metrics endpoint stopped", "err", err)
+		}
+	}()
+	slog.Info("serving metrics", "url", "http://"+lis.Addr().String()+"/metrics")
+	return nil
+}
+
+// splitMethod turns /kv.KVStore/Get into kv.KVStore and Get
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.IndexByte(fullMethod, '/'); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+func observeCall(fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	rpcRequests.WithLabelValues(service, method).Inc()
+	rpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		rpcErrors.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	}
+}
+
+// metricsUnaryInterceptor measures every unary call, it runs first so calls
+// rejected by authentication or limits are counted too
+func metricsUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	observeCall(info.FullMethod, start, err)
+	return res, err
+}
+
+func metricsStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeCall(info.FullMethod, start, err)
+	return err
+}
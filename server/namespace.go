@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the keys of namespace ns are stored as nsPrefix + ns + "/" + key, so like
+// every reserved key they are out of sight of the default namespace, and are
+// logged, snapshotted and replicated as any other key
+const nsPrefix = reservedPrefix + "ns/"
+
+// quotaPrefix + namespace holds the quota of the namespace
+const quotaPrefix = reservedPrefix + "quota/"
+
+var namespaceName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+func validNamespace(name string) bool {
+	return name == "" || namespaceName.MatchString(name)
+}
+
+// nsKeyPrefix is what the stored keys of the namespace start with
+func nsKeyPrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return nsPrefix + name + "/"
+}
+
+// splitKey returns the namespace of a stored key and the key within it, ok is
+// false for the keys of the server itself
+func splitKey(key string) (string, string, bool) {
+	if !reservedKey(key) {
+		return "", key, true
+	}
+	rest := strings.TrimPrefix(key, nsPrefix)
+	i := strings.IndexByte(rest, '/')
+	if rest == key || i < 0 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+// displayKey is the key as its namespace knows it
+func displayKey(key string) string {
+	if _, k, ok := splitKey(key); ok {
+		return k
+	}
+	return key
+}
+
+// visible reports whether a key matched by prefix may be shown, reserved keys
+// only match a reserved prefix, i.e. that of their namespace
+func visible(key string, prefix string) bool {
+	return strings.HasPrefix(key, prefix) && (reservedKey(prefix) || !reservedKey(key))
+}
+
+type nsOp int
+
+const (
+	opGet nsOp = iota
+	opSet
+	opDelete
+	opRead // getPrefix, scan and watch
+	numNsOps
+)
+
+// nsState is the usage of one namespace. The key count and bytes follow every
+// stored change, so recovery rebuilds them; the op counts start at zero.
+type nsState struct {
+	keys     int64 // keep the atomics first for 64-bit alignment
+	bytes    int64
+	ops      [numNsOps]int64
+	rejected int64
+	name     string
+	prefix   string
+
+	lock    sync.Mutex
+	version int64 // of the quota entry last parsed
+	quota   *pb.Quota
+	bucket  *tokenBucket // nil without an ops/sec limit
+
+	// usage reserved by sets admitted but not applied yet, under lock
+	pendingKeys  int64
+	pendingBytes int64
+}
+
+// namespaces tracks every namespace in use
+type namespaces struct {
+	s      *ServerMgr
+	lock   sync.RWMutex
+	byName map[string]*nsState
+}
+
+func newNamespaces(s *ServerMgr) *namespaces {
+	return &namespaces{s: s, byName: make(map[string]*nsState)}
+}
+
+// lookup returns the state of a namespace without keeping one for a name
+// never written to, so reads of unknown names do not pile up
+func (n *namespaces) lookup(name string) *nsState {
+	n.lock.RLock()
+	ns, ok := n.byName[name]
+	n.lock.RUnlock()
+	if ok {
+		return ns
+	}
+	return &nsState{name: name, prefix: nsKeyPrefix(name)}
+}
+
+// get returns the state of a namespace, kept from then on
+func (n *namespaces) get(name string) *nsState {
+	n.lock.RLock()
+	ns, ok := n.byName[name]
+	n.lock.RUnlock()
+	if ok {
+		return ns
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if ns, ok = n.byName[name]; !ok {
+		ns = &nsState{name: name, prefix: nsKeyPrefix(name)}
+		n.byName[name] = ns
+	}
+	return ns
+}
+
+// account follows a stored change of the key in the usage of its namespace
+func (n *namespaces) account(key string, old cacheEntry, existed bool, entry cacheEntry) {
+	name, k, ok := splitKey(key)
+	if !ok {
+		if name := strings.TrimPrefix(key, quotaPrefix); name != key {
+			n.get(name) // list namespaces with a quota even while unused
+		}
+		return
+	}
+	var keys, bytes int64
+	if existed && !old.Deleted {
+		keys, bytes = -1, -int64(len(k)+len(old.Value))
+	}
+	if !entry.Deleted {
+		keys, bytes = keys+1, bytes+int64(len(k)+len(entry.Value))
+	}
+	if keys != 0 || bytes != 0 {
+		ns := n.get(name)
+		atomic.AddInt64(&ns.keys, keys)
+		atomic.AddInt64(&ns.bytes, bytes)
+	}
+}
+
+// enter admits an op on the namespace if it is within its ops/sec limit. Only
+// sets, stored keys and quotas make a namespace known.
+func (n *namespaces) enter(ctx context.Context, name string, op nsOp) (*nsState, error) {
+	if !validNamespace(name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid namespace %q", name)
+	}
+	ns := n.lookup(name)
+	if op == opSet {
+		ns = n.get(name)
+	}
+	atomic.AddInt64(&ns.ops[op], 1)
+	if quota, bucket := ns.limits(n.s); bucket != nil {
+		if ok, wait := bucket.take(time.Now()); !ok {
+			atomic.AddInt64(&ns.rejected, 1)
+			return nil, exhausted(ctx, wait, "namespace %q is over its quota of %d ops/sec", name, quota.GetMaxOpsPerSec())
+		}
+	}
+	return ns, nil
+}
+
+// limits returns the quota of the namespace, read from the store
+func (ns *nsState) limits(s *ServerMgr) (*pb.Quota, *tokenBucket) {
+	var version int64
+	value := ""
+	if tmp, ok := s.inMemoryCache.Get(quotaPrefix + ns.name); ok {
+		entry := tmp.(cacheEntry)
+		if version = entry.Version; !entry.Deleted {
+			value = entry.Value
+		}
+	}
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	if ns.quota == nil || ns.version != version {
+		ns.quota, ns.version, ns.bucket = decodeQuota(ns.name, value), version, nil
+		if rate := ns.quota.GetMaxOpsPerSec(); rate > 0 {
+			ns.bucket = newTokenBucket(float64(rate), float64(rate))
+		}
+	}
+	return ns.quota, ns.bucket
+}
+
+func noRelease() {}
+
+// reserveWrite rejects setting the key if it takes the namespace over its
+// quota of keys or bytes, else reserves the growth until release is called
+// once the write is applied or failed, so concurrent sets cannot all pass the
+// check. Quorum writes are checked against the usage seen here.
+func (ns *nsState) reserveWrite(s *ServerMgr, key string, value string) (release func(), err error) {
+	quota, _ := ns.limits(s)
+	if quota.GetMaxKeys() == 0 && quota.GetMaxBytes() == 0 {
+		return noRelease, nil
+	}
+	keys, bytes := int64(1), int64(len(key)+len(value))
+	if tmp, ok := s.inMemoryCache.Get(ns.prefix + key); ok && !tmp.(cacheEntry).Deleted {
+		keys, bytes = 0, bytes-int64(len(key)+len(tmp.(cacheEntry).Value))
+	}
+	if bytes < 0 {
+		bytes = 0 // a shrinking value frees its bytes once applied
+	}
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	// an applied write counts twice until released, erring on the safe side
+	if max := quota.GetMaxKeys(); max > 0 && keys > 0 && atomic.LoadInt64(&ns.keys)+ns.pendingKeys+keys > max {
+		err = status.Errorf(codes.ResourceExhausted, "namespace %q holds its quota of %d keys", ns.name, max)
+	} else if max := quota.GetMaxBytes(); max > 0 && bytes > 0 && atomic.LoadInt64(&ns.bytes)+ns.pendingBytes+bytes > max {
+		err = status.Errorf(codes.ResourceExhausted, "namespace %q would exceed its quota of %d bytes", ns.name, max)
+	}
+	if err != nil {
+		atomic.AddInt64(&ns.rejected, 1)
+		return noRelease, err
+	}
+	ns.pendingKeys += keys
+	ns.pendingBytes += bytes
+	return func() {
+		ns.lock.Lock()
+		ns.pendingKeys -= keys
+		ns.pendingBytes -= bytes
+		ns.lock.Unlock()
+	}, nil
+}
+
+func (ns *nsState) stats(s *ServerMgr) *pb.NamespaceStats {
+	quota, _ := ns.limits(s)
+	return &pb.NamespaceStats{
+		Namespace: ns.name,
+		Quota:     quota,
+		Keys:      atomic.LoadInt64(&ns.keys),
+		Bytes:     atomic.LoadInt64(&ns.bytes),
+		Gets:      atomic.LoadInt64(&ns.ops[opGet]),
+		Sets:      atomic.LoadInt64(&ns.ops[opSet]),
+		Deletes:   atomic.LoadInt64(&ns.ops[opDelete]),
+		Reads:     atomic.LoadInt64(&ns.ops[opRead]),
+		Rejected:  atomic.LoadInt64(&ns.rejected),
+	}
+}
+
+// encodeQuota lays out the limits as space separated name=value pairs
+func encodeQuota(q *pb.Quota) string {
+	return fmt.Sprintf("max_keys=%d max_bytes=%d max_ops_per_sec=%d", q.GetMaxKeys(), q.GetMaxBytes(), q.GetMaxOpsPerSec())
+}
+
+func decodeQuota(name string, value string) *pb.Quota {
+	q := &pb.Quota{Namespace: name}
+	for _, part := range strings.Fields(value) {
+		i := strings.IndexByte(part, '=')
+		if i < 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(part[i+1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch part[:i] {
+		case "max_keys":
+			q.MaxKeys = n
+		case "max_bytes":
+			q.MaxBytes = n
+		case "max_ops_per_sec":
+			q.MaxOpsPerSec = n
+		}
+	}
+	return q
+}
+
+// SetQuota replaces the limits of a namespace, all zero removes them
+func (s *ServerMgr) SetQuota(ctx context.Context, q *pb.Quota) (*pb.Empty, error) {
+	if !validNamespace(q.GetNamespace()) {
+		return &pb.Empty{}, status.Errorf(codes.InvalidArgument, "invalid namespace %q", q.GetNamespace())
+	}
+	if q.GetMaxKeys() < 0 || q.GetMaxBytes() < 0 || q.GetMaxOpsPerSec() < 0 {
+		return &pb.Empty{}, status.Errorf(codes.InvalidArgument, "limits must not be negative")
+	}
+	entry := cacheEntry{Value: encodeQuota(q)}
+	if q.GetMaxKeys() == 0 && q.GetMaxBytes() == 0 && q.GetMaxOpsPerSec() == 0 {
+		entry = cacheEntry{Deleted: true}
+	}
+	return &pb.Empty{}, writeHelper(ctx, s, quotaPrefix+q.GetNamespace(), entry, nil)
+}
+
+// GetNamespaces returns the quota and usage of the requested namespaces, or
+// of every namespace holding keys, having a quota or written to since the start
+func (s *ServerMgr) GetNamespaces(ctx context.Context, req *pb.GetNamespacesRequest) (*pb.GetNamespacesResponse, error) {
+	names := req.GetNamespaces()
+	if len(names) == 0 {
+		s.namespaces.lock.RLock()
+		for name := range s.namespaces.byName {
+			names = append(names, name)
+		}
+		s.namespaces.lock.RUnlock()
+		sort.Strings(names)
+	}
+	var stats []*pb.NamespaceStats
+	for _, name := range names {
+		if !validNamespace(name) {
+			return &pb.GetNamespacesResponse{}, status.Errorf(codes.InvalidArgument, "invalid namespace %q", name)
+		}
+		stats = append(stats, s.namespaces.lookup(name).stats(s))
+	}
+	return &pb.GetNamespacesResponse{Namespaces: stats}, nil
+}
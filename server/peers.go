@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+)
+
+// peerSet is the other kvservers this server replicates with, each peer gets
+// one lazily dialed connection
+type peerSet struct {
+	self  string
+	addrs []string
+	lock  sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// peerDialOptions secure the connections to peers, with the TLS and the
+// token of the server if it has them
+var peerDialOptions = []grpc.DialOption{grpc.WithInsecure()}
+
+// tracePeerCall traces the calls to peers made within a traced call, e.g. the
+// puts of a quorum write, but not heartbeats and anti-entropy rounds
+func tracePeerCall(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	return tracing.UnaryClientInterceptor(ctx, method, req, reply, cc, invoker, opts...)
+}
+
+func newPeerSet(self string, addrs []string) *peerSet {
+	return &peerSet{self: self, addrs: addrs, conns: make(map[string]*grpc.ClientConn)}
+}
+
+// nodes returns this server and all its peers
+func (p *peerSet) nodes() []string {
+	return append([]string{p.self}, p.addrs...)
+}
+
+func (p *peerSet) replicaClient(addr string) (pb.ReplicaClient, error) {
+	conn, err := p.conn(addr)
+	if err != nil {
+		return nil, err
+	}
+	return pb.NewReplicaClient(conn), nil
+}
+
+// kvClient is used to forward the reads this server is too far behind to serve
+func (p *peerSet) kvClient(addr string) (pb.KVStoreClient, error) {
+	conn, err := p.conn(addr)
+	if err != nil {
+		return nil, err
+	}
+	return pb.NewKVStoreClient(conn), nil
+}
+
+func (p *peerSet) conn(addr string) (*grpc.ClientConn, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+	// reconnect at least as often as heartbeats are sent, so a peer coming back
+	// is not hidden behind the default backoff of up to two minutes
+	backoffConfig := backoff.DefaultConfig
+	backoffConfig.MaxDelay = kasp.Time
+	conn, err := grpc.Dial(addr, append(peerDialOptions, grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoffConfig}),
+		grpc.WithChainUnaryInterceptor(tracePeerCall))...)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const replicaTimeout = 5 * time.Second
+const hintReplayInterval = 5 * time.Second
+
+// hint is a write a down replica missed, kept by the coordinator until the
+// replica is back
+type hint struct {
+	Target   string
+	Key      string
+	Value    string
+	Version  int64
+	Deleted  bool
+	Replicas uint32 `json:",omitempty"`
+}
+
+func (hh hint) entry() cacheEntry {
+	return cacheEntry{Value: hh.Value, Version: hh.Version, Deleted: hh.Deleted, Replicas: hh.Replicas}
+}
+
+// hintStore keeps the hints in memory and in an append-only file, so they
+// survive a restart of the coordinator
+type hintStore struct {
+	lock     sync.Mutex
+	filename string
+	file     *os.File
+	hints    []hint
+	keys     *keyring // seals the hints on disk, nil for plaintext
+}
+
+// preferenceList picks the n nodes that own the key by rendezvous hashing, so
+// every server computes the same list from the same set of nodes
+func preferenceList(nodes []string, key string, n int) []string {
+	weights := make(map[string]uint64, len(nodes))
+	for _, node := range nodes {
+		h := fnv.New64a()
+		h.Write([]byte(node))
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+		weights[node] = h.Sum64()
+	}
+	sorted := append([]string{}, nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return weights[sorted[i]] > weights[sorted[j]] })
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[:n]
+}
+
+// heldBy tells whether every one of the nodes keeps the entry: all of them
+// when it was written without a quorum, else only its owners. An empty node
+// is anyone.
+func (s *ServerMgr) heldBy(key string, entry cacheEntry, nodes ...string) bool {
+	if entry.Replicas == 0 {
+		return true
+	}
+	owners := preferenceList(s.peers.nodes(), key, int(entry.Replicas))
+	for _, node := range nodes {
+		found := node == ""
+		for _, owner := range owners {
+			found = found || owner == node
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func checkQuorum(s *ServerMgr, q *pb.Quorum) error {
+	nodes := len(s.peers.nodes())
+	n, r, w := q.GetN(), q.GetR(), q.GetW()
+	if int(n) > nodes {
+		return status.Errorf(codes.InvalidArgument, "quorum n=%d exceeds the %d known servers", n, nodes)
+	}
+	if r < 1 || r > n || w < 1 || w > n {
+		return status.Errorf(codes.InvalidArgument, "quorum needs 1 <= r, w <= n, got n=%d r=%d w=%d", n, r, w)
+	}
+	return nil
+}
+
+// Put stores a version of the key sent by a quorum coordinator
+func (s *ServerMgr) Put(ctx context.Context, e *pb.Entry) (*pb.Empty, error) {
+	_, err := applyEntry(ctx, s, e.GetKey(), fromEntry(e))
+	return &pb.Empty{}, err
+}
+
+// Fetch returns the version of the key held by this server, for quorum reads
+func (s *ServerMgr) Fetch(ctx context.Context, req *pb.FetchRequest) (*pb.FetchResponse, error) {
+	if tmp, ok := s.inMemoryCache.Get(req.GetKey()); ok {
+		entry := tmp.(cacheEntry)
+		return &pb.FetchResponse{Entry: toEntry(req.GetKey(), entry), Found: true}, nil
+	}
+	return &pb.FetchResponse{}, nil
+}
+
+// putTo sends the write to one owner, within the trace of ctx but not its
+// deadline since the write goes on after the quorum answered
+func putTo(ctx context.Context, s *ServerMgr, node string, key string, entry cacheEntry) error {
+	if node == s.peers.self {
+		_, err := applyEntry(ctx, s, key, entry)
+		return err
+	}
+	client, err := s.peers.replicaClient(node)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(tracing.Detach(ctx), replicaTimeout)
+	defer cancel()
+	_, err = client.Put(ctx, toEntry(key, entry))
+	return err
+}
+
+func fetchFrom(s *ServerMgr, node string, key string) (cacheEntry, bool, error) {
+	if node == s.peers.self {
+		tmp, ok := s.inMemoryCache.Get(key)
+		if !ok {
+			return cacheEntry{}, false, nil
+		}
+		return tmp.(cacheEntry), true, nil
+	}
+	client, err := s.peers.replicaClient(node)
+	if err != nil {
+		return cacheEntry{}, false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), replicaTimeout)
+	defer cancel()
+	res, err := client.Fetch(ctx, &pb.FetchRequest{Key: key})
+	if err != nil {
+		return cacheEntry{}, false, err
+	}
+	return fromEntry(res.GetEntry()), res.GetFound(), nil
+}
+
+// quorumSet sends the write, a set or a delete, to the n owners of the key and
+// returns once w of them acknowledged it. Owners that cannot be reached get a
+// hint, replayed by this server when they come back. Hints do not count towards w.
+func quorumSet(ctx context.Context, s *ServerMgr, key string, entry cacheEntry, q *pb.Quorum) error {
+	entry.Version, entry.Replicas = nextVersion(s, key), q.GetN()
+	nodes := preferenceList(s.peers.nodes(), key, int(q.GetN()))
+	acks := make(chan error, len(nodes))
+	for _, node := range nodes {
+		go func(node string) {
+			var err error
+			if s.members.isAlive(node) {
+				err = putTo(ctx, s, node, key, entry)
+			} else {
+				err = status.Errorf(codes.Unavailable, "replica %s is down", node)
+			}
+			if err != nil && node != s.peers.self {
+				s.hints.add(hint{Target: node, Key: key, Value: entry.Value, Version: entry.Version, Deleted: entry.Deleted, Replicas: entry.Replicas})
+			}
+			acks <- err
+		}(node)
+	}
+
+	succeeded, failed := 0, 0
+	for succeeded < int(q.GetW()) && failed <= len(nodes)-int(q.GetW()) {
+		if err := <-acks; err != nil {
+			logger(ctx).Warn("quorum set failed on a replica", "key", key, "err", err)
+			failed++
+		} else {
+			succeeded++
+		}
+	}
+	if succeeded < int(q.GetW()) {
+		return status.Errorf(codes.Unavailable, "only %d of %d replicas acknowledged the write", succeeded, q.GetW())
+	}
+	return nil
+}
+
+type fetchResult struct {
+	node  string
+	entry cacheEntry
+	found bool
+	err   error
+}
+
+// quorumGet asks the n owners of the key and returns the newest version among
+// the first r answers. Owners found holding an older version are repaired in
+// the background, including the ones answering after the read returned.
+func quorumGet(s *ServerMgr, key string, q *pb.Quorum) (cacheEntry, bool, error) {
+	nodes := preferenceList(s.peers.nodes(), key, int(q.GetN()))
+	results := make(chan fetchResult, len(nodes))
+	for _, node := range nodes {
+		go func(node string) {
+			if !s.members.isAlive(node) {
+				results <- fetchResult{node: node, err: status.Errorf(codes.Unavailable, "replica %s is down", node)}
+				return
+			}
+			entry, found, err := fetchFrom(s, node, key)
+			results <- fetchResult{node: node, entry: entry, found: found, err: err}
+		}(node)
+	}
+
+	answers := []fetchResult{}
+	failed := 0
+	for len(answers) < int(q.GetR()) && failed <= len(nodes)-int(q.GetR()) {
+		res := <-results
+		if res.err != nil {
+			failed++
+			continue
+		}
+		answers = append(answers, res)
+	}
+	if len(answers) < int(q.GetR()) {
+		return cacheEntry{}, false, status.Errorf(codes.Unavailable, "only %d of %d replicas answered the read", len(answers), q.GetR())
+	}
+
+	var newest cacheEntry
+	found := false
+	for _, res := range answers {
+		if res.found && (!found || newerEntry(res.entry, newest)) {
+			newest, found = res.entry, true
+		}
+	}
+	if found {
+		go readRepair(s, key, newest, answers, results, len(nodes)-len(answers)-failed)
+	}
+	return newest, found, nil
+}
+
+func readRepair(s *ServerMgr, key string, newest cacheEntry, answers []fetchResult, results <-chan fetchResult, pending int) {
+	for i := 0; i < pending; i++ {
+		if res := <-results; res.err == nil {
+			answers = append(answers, res)
+		}
+	}
+	for _, res := range answers {
+		if res.found && !newerEntry(newest, res.entry) {
+			continue
+		}
+		if err := putTo(context.Background(), s, res.node, key, newest); err != nil {
+			slog.Warn("read repair failed", "key", key, "node", res.node, "err", err)
+		}
+	}
+}
+
+func newHintStore(filename string, keys *keyring) (*hintStore, error) {
+	h := &hintStore{filename: filename, keys: keys}
+	if iFile, err := os.Open(filename); err == nil {
+		reader := bufio.NewReader(iFile)
+		dropped := false
+		for n := 1; ; n++ {
+			line, rerr := reader.ReadString('\n')
+			if line = strings.TrimSpace(line); line != "" {
+				var hh hint
+				plain, err := keys.open(line)
+				if err == nil {
+					err = json.Unmarshal([]byte(plain), &hh)
+				}
+				if err != nil {
+					// a hint cut short by a crash, or damaged, only loses itself
+					slog.Warn("dropping an unreadable hint", "file", filename, "line", n, "err", err)
+					dropped = true
+				} else {
+					h.hints = append(h.hints, hh)
+				}
+			}
+			if rerr != nil {
+				break
+			}
+		}
+		iFile.Close()
+		if dropped { // so that the next hint does not land on a torn line
+			if err := h.rewrite(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	h.file = file
+	return h, nil
+}
+
+func (h *hintStore) add(hh hint) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.hints = append(h.hints, hh)
+	if err := h.write(h.file, hh); err != nil {
+		slog.Error("failed to persist hint", "target", hh.Target, "err", err)
+		return
+	}
+	h.file.Sync()
+}
+
+// write appends the hint to the file as a line of JSON, sealed when
+// encryption is on
+func (h *hintStore) write(file *os.File, hh hint) error {
+	data, err := json.Marshal(hh)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(h.keys.seal(string(data)) + "\n")
+	return err
+}
+
+// replay hands every hint to its target, and rewrites the hint file with the
+// ones that still could not be delivered
+func (h *hintStore) replay(s *ServerMgr) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.hints) == 0 {
+		return
+	}
+	remaining := []hint{}
+	down := make(map[string]bool)
+	for _, hh := range h.hints {
+		if _, ok := down[hh.Target]; !ok {
+			down[hh.Target] = !s.members.isAlive(hh.Target)
+		}
+		if down[hh.Target] {
+			remaining = append(remaining, hh)
+			continue
+		}
+		if err := putTo(context.Background(), s, hh.Target, hh.Key, hh.entry()); err != nil {
+			down[hh.Target] = true
+			remaining = append(remaining, hh)
+		}
+	}
+	if delivered := len(h.hints) - len(remaining); delivered > 0 {
+		slog.Info("hinted handoff delivered writes", "writes", delivered)
+	}
+	h.hints = remaining
+	if err := h.rewrite(); err != nil {
+		slog.Error("failed to rewrite hints", "err", err)
+		return
+	}
+	h.file.Close()
+	var err error
+	h.file, err = os.OpenFile(h.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		slog.Error("failed to reopen hints", "err", err)
+	}
+}
+
+// rewrite replaces the hint file with the hints held
+func (h *hintStore) rewrite() error {
+	tmpName := h.filename + ".tmp"
+	newFile, err := os.OpenFile(tmpName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	for _, hh := range h.hints {
+		if err = h.write(newFile, hh); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = newFile.Sync()
+	}
+	newFile.Close()
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return os.Rename(tmpName, h.filename)
+}
+
+// run replays the hints periodically, and as soon as a peer comes back
+func (h *hintStore) run(s *ServerMgr, events <-chan memberEvent) {
+	ticker := time.NewTicker(hintReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case ev := <-events:
+			if ev.to != pb.MemberState_ALIVE {
+				continue
+			}
+		}
+		h.replay(s)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// retryAfterKey is the trailer telling a rejected caller how many
+// milliseconds to wait before trying again
+const retryAfterKey = "retry-after-ms"
+
+// idleBucket is how long the bucket of a silent caller is kept
+const idleBucket = time.Minute
+
+// exhausted rejects a call with ResourceExhausted and a retry-after hint
+func exhausted(ctx context.Context, wait time.Duration, format string, a ...interface{}) error {
+	ms := int64(wait / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	grpc.SetTrailer(ctx, metadata.Pairs(retryAfterKey, strconv.FormatInt(ms, 10)))
+	return status.Errorf(codes.ResourceExhausted, format, a...)
+}
+
+// callerOf names the caller for rate limiting: its principal when
+// authentication is on, the host it calls from otherwise
+func callerOf(ctx context.Context) string {
+	if principal := principalFrom(ctx); principal != "" {
+		return principal
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// rateLimiter keeps a token bucket per caller
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = int(rate + 0.999)
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*tokenBucket), swept: time.Now()}
+}
+
+func (l *rateLimiter) take(caller string, now time.Time) (bool, time.Duration) {
+	l.lock.Lock()
+	if now.Sub(l.swept) > idleBucket {
+		// a bucket idle that long is full again, dropping it changes nothing
+		for name, b := range l.buckets {
+			b.lock.Lock()
+			idle := now.Sub(b.last) > idleBucket
+			b.lock.Unlock()
+			if idle {
+				delete(l.buckets, name)
+			}
+		}
+		l.swept = now
+	}
+	b, ok := l.buckets[caller]
+	if !ok {
+		b = newTokenBucket(l.rate, l.burst)
+		l.buckets[caller] = b
+	}
+	l.lock.Unlock()
+	return b.take(now)
+}
+
+// admission bounds the unary calls served at once. Calls beyond the bound
+// wait in a queue and are shed when maxQueue calls are already waiting, or
+// after waiting for timeout.
+type admission struct {
+	queued   int64 // keep the atomics first for 64-bit alignment
+	latency  int64 // moving average of the time a call holds its slot, in nanoseconds
+	slots    chan struct{}
+	maxQueue int64
+	timeout  time.Duration
+}
+
+func newAdmission(maxInFlight int, maxQueue int, timeout time.Duration) *admission {
+	return &admission{slots: make(chan struct{}, maxInFlight), maxQueue: int64(maxQueue), timeout: timeout}
+}
+
+// acquire waits for a slot and returns the function giving it back
+func (a *admission) acquire(ctx context.Context) (func(), error) {
+	select {
+	case a.slots <- struct{}{}:
+		return a.release(time.Now()), nil
+	default:
+	}
+	depth := atomic.AddInt64(&a.queued, 1)
+	defer atomic.AddInt64(&a.queued, -1)
+	if depth > a.maxQueue {
+		return nil, exhausted(ctx, a.retryAfter(depth), "server overloaded, %d calls in flight and %d queued", cap(a.slots), depth-1)
+	}
+	timer := time.NewTimer(a.timeout)
+	defer timer.Stop()
+	select {
+	case a.slots <- struct{}{}:
+		return a.release(time.Now()), nil
+	case <-timer.C:
+		return nil, exhausted(ctx, a.retryAfter(depth), "server overloaded, no call slot within %v", a.timeout)
+	case <-ctx.Done():
+		return nil, status.Error(codes.Canceled, ctx.Err().Error())
+	}
+}
+
+func (a *admission) release(start time.Time) func() {
+	return func() {
+		<-a.slots
+		// not exact under contention, good enough for a hint
+		avg := atomic.LoadInt64(&a.latency)
+		atomic.StoreInt64(&a.latency, avg+(int64(time.Since(start))-avg)/8)
+	}
+}
+
+// retryAfter guesses how long the calls ahead of a queue position take
+func (a *admission) retryAfter(depth int64) time.Duration {
+	wait := time.Duration(atomic.LoadInt64(&a.latency) * depth / int64(cap(a.slots)))
+	if wait < 10*time.Millisecond {
+		wait = 10 * time.Millisecond
+	}
+	return wait
+}
+
+// limits protects the server from callers asking too much, a nil field is no limit
+type limits struct {
+	clients  *rateLimiter
+	inFlight *admission
+}
+
+// limited calls are those of clients, peers replicate and health checks
+// answer whatever the load
+func limited(method string) bool {
+	return strings.HasPrefix(method, "/kv.KVStore/") || strings.HasPrefix(method, "/kv.Admin/")
+}
+
+func (l *limits) allow(ctx context.Context) error {
+	if l.clients == nil {
+		return nil
+	}
+	caller := callerOf(ctx)
+	if ok, wait := l.clients.take(caller, time.Now()); !ok {
+		return exhausted(ctx, wait, "%s is over the rate limit of %g calls/sec", caller, l.clients.rate)
+	}
+	return nil
+}
+
+// limitUnaryInterceptor rate limits unary calls and bounds those in flight,
+// it runs after authentication to know the principal
+func limitUnaryInterceptor(l *limits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !limited(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if err := l.allow(ctx); err != nil {
+			return nil, err
+		}
+		if l.inFlight != nil {
+			release, err := l.inFlight.acquire(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// limitStreamInterceptor rate limits opening streams. Streams do not count as
+// in flight, a watch would hold its slot for good.
+func limitStreamInterceptor(l *limits) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if limited(info.FullMethod) {
+			if err := l.allow(ss.Context()); err != nil {
+				return err
+			}
+		}
+		return handler(srv, ss)
+	}
+}
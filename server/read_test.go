@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckReadOptions(t *testing.T) {
+	s := NewServerMgr("normal", "a:1")
+	s.peers = newPeerSet("a:1", []string{"b:1"})
+	s.members = newMembership(s)
+	now := time.Now().UnixNano()
+	s.lastWrite = now
+	s.members.heard("b:1", now+int64(50*time.Millisecond))
+	forwarded := metadata.NewIncomingContext(context.Background(), metadata.Pairs(forwardedKey, "c:1"))
+
+	for _, tc := range []struct {
+		name      string
+		ctx       context.Context
+		opts      *pb.ReadOptions
+		forwardTo string
+		code      codes.Code
+	}{
+		{"linearizable behind", context.Background(), nil, "b:1", codes.OK},
+		{"linearizable forwarded", forwarded, nil, "", codes.FailedPrecondition},
+		{"any", context.Background(), &pb.ReadOptions{Consistency: pb.ReadConsistency_ANY}, "", codes.OK},
+		{"within bound", context.Background(), &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MaxStalenessMs: 100}, "", codes.OK},
+		{"over bound", context.Background(), &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MaxStalenessMs: 10}, "b:1", codes.OK},
+		{"over bound forwarded", forwarded, &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MaxStalenessMs: 10}, "", codes.FailedPrecondition},
+		{"revision reached", context.Background(), &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MinRevision: now}, "", codes.OK},
+		{"revision on peer", context.Background(), &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MinRevision: now + 1}, "b:1", codes.OK},
+		{"revision nowhere", context.Background(), &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MinRevision: now + int64(time.Second)}, "", codes.FailedPrecondition},
+		{"negative bound", context.Background(), &pb.ReadOptions{Consistency: pb.ReadConsistency_BOUNDED_STALENESS, MaxStalenessMs: -1}, "", codes.InvalidArgument},
+	} {
+		st, err := checkReadOptions(tc.ctx, s, tc.opts)
+		if status.Code(err) != tc.code || st.forwardTo != tc.forwardTo {
+			t.Errorf("%s: forward to %q, err %v, want %q and %v", tc.name, st.forwardTo, err, tc.forwardTo, tc.code)
+		}
+		if st.revision != now || st.stalenessMs != 50 {
+			t.Errorf("%s: revision %d staleness %dms, want %d and 50ms", tc.name, st.revision, st.stalenessMs, now)
+		}
+	}
+
+	// once caught up, linearizable reads are served here
+	s.lastWrite = now + int64(50*time.Millisecond)
+	if st, err := checkReadOptions(context.Background(), s, nil); err != nil || st.forwardTo != "" || st.stalenessMs != 0 {
+		t.Errorf("current replica: forward to %q, staleness %dms, err %v", st.forwardTo, st.stalenessMs, err)
+	}
+}
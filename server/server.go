@@ -1,17 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tlsconfig"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -22,6 +34,39 @@ var (
 	datasetFile string = "history.log"
 	mode        string = "normal"
 	exp_time    int    = 120
+	peerList    string = ""
+	advertise   string = ""
+	aeInterval  int    = 30
+
+	tlsCert              string = ""
+	tlsKey               string = ""
+	tlsClientCA          string = ""
+	tlsRequireClientCert bool   = false
+
+	authTokens string = ""
+	authMTLS   bool   = false
+	authAdmins string = ""
+	peerToken  string = ""
+
+	rateLimit      float64 = 0
+	rateBurst      int     = 0
+	maxInFlight    int     = 0
+	maxQueue       int     = -1
+	queueTimeoutMs int     = 1000
+
+	encryptionKeyFile string = ""
+	encryptionKeyEnv  string = ""
+
+	metricsAddr string = ""
+
+	traceExporter    string  = ""
+	traceEndpoint    string  = tracing.DefaultEndpoint
+	traceSampleRatio float64 = 1
+
+	logLevelName string = "info"
+
+	shutdownTimeout int  = 10
+	snapshotOnExit  bool = false
 )
 
 var (
@@ -45,51 +90,198 @@ func main() {
 	flag.StringVar(&mode, "mode", mode, "server's mode e.g. normal and test mode")
 	flag.StringVar(&serverIp, "ip", serverIp, "the target server's ip address")
 	flag.StringVar(&datasetFile, "dataset", datasetFile, "dataset for benchmark, e.g. KV_10k_128B_512B.txt")
+	flag.StringVar(&peerList, "peers", peerList, "comma separated replica addresses to keep in sync, e.g. host1:6000,host2:6000")
+	flag.StringVar(&advertise, "advertise", advertise, "the address peers know this server by, defaults to ip:port")
+	flag.IntVar(&aeInterval, "anti_entropy_interval", aeInterval, "seconds between anti-entropy rounds with the peers")
+	flag.StringVar(&tlsCert, "tls_cert", tlsCert, "PEM certificate of the server, enables TLS, reloaded when the file changes")
+	flag.StringVar(&tlsKey, "tls_key", tlsKey, "PEM private key of -tls_cert")
+	flag.StringVar(&tlsClientCA, "tls_client_ca", tlsClientCA, "PEM CA certificates verifying client certificates, and the certificates of -peers")
+	flag.BoolVar(&tlsRequireClientCert, "tls_require_client_cert", tlsRequireClientCert, "reject clients without a certificate signed by -tls_client_ca (mutual TLS)")
+	flag.StringVar(&authTokens, "auth_tokens", authTokens, "file of `principal token` lines, callers must then send one of the tokens")
+	flag.BoolVar(&authMTLS, "auth_mtls", authMTLS, "identify callers by the common name of their client certificate, see -tls_client_ca")
+	flag.StringVar(&authAdmins, "auth_admins", authAdmins, "comma separated principals with admin on every key, e.g. to set the first ACLs")
+	flag.StringVar(&peerToken, "peer_token", peerToken, "token this server sends to its -peers, when they check tokens")
+	flag.Float64Var(&rateLimit, "rate_limit", rateLimit, "calls a second allowed to each client, by principal or else host, 0 for no limit")
+	flag.IntVar(&rateBurst, "rate_burst", rateBurst, "calls a client may make at once above -rate_limit, defaults to -rate_limit")
+	flag.IntVar(&maxInFlight, "max_in_flight", maxInFlight, "unary calls served at once, more wait in a queue, 0 for no limit")
+	flag.IntVar(&maxQueue, "max_queue", maxQueue, "calls waiting for -max_in_flight before new ones are shed, defaults to -max_in_flight")
+	flag.IntVar(&queueTimeoutMs, "queue_timeout_ms", queueTimeoutMs, "milliseconds a queued call waits before it is shed")
+	flag.StringVar(&encryptionKeyFile, "encryption_key_file", encryptionKeyFile, "file of `id:hexkey` AES keys encrypting history.log, snapshots and hints, the last one current")
+	flag.StringVar(&encryptionKeyEnv, "encryption_key_env", encryptionKeyEnv, "environment variable holding the keys instead of -encryption_key_file")
+	flag.StringVar(&metricsAddr, "metrics_addr", metricsAddr, "address serving Prometheus metrics on /metrics, e.g. :9100, off if empty")
+	flag.StringVar(&traceExporter, "trace_exporter", traceExporter, "where OpenTelemetry spans go, `stdout` or `otlp`, tracing is off if empty")
+	flag.StringVar(&traceEndpoint, "trace_endpoint", traceEndpoint, "OTLP/HTTP traces URL of the collector for -trace_exporter otlp")
+	flag.Float64Var(&traceSampleRatio, "trace_sample_ratio", traceSampleRatio, "share of the calls without a sampled caller that are traced, 0 to 1")
+	flag.StringVar(&logLevelName, "log_level", logLevelName, "level of the JSON log on stderr, `debug`, `info`, `warn` or `error`, see also kvctl loglevel")
+	flag.IntVar(&shutdownTimeout, "shutdown_timeout", shutdownTimeout, "seconds calls in flight have to finish on SIGINT or SIGTERM before they are cancelled")
+	flag.BoolVar(&snapshotOnExit, "snapshot_on_exit", snapshotOnExit, "write snapshot.json on shutdown, loaded instead of replaying history.log on the next start")
 	flag.Parse()
 
+	if err := setupLogging(logLevelName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := run(); err != nil {
+		slog.Error("server failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run serves until the server stops, errors setting it up are returned
+func run() error {
 	lis, err := net.Listen("tcp", serverIp+":"+strconv.Itoa(port))
 	if err != nil {
-		log.Printf("failed to listen: %v", err)
-		return
+		return fmt.Errorf("failed to listen: %v", err)
 	}
 
 	start := time.Now()
-	s := NewServerMgr(mode)
-	if _, err := os.Stat(datasetFile); err == nil {
-		s.LoadFromHistoryLog(datasetFile)
+	if advertise == "" {
+		advertise = serverIp + ":" + strconv.Itoa(port)
+	}
+	s := NewServerMgr(mode, advertise)
+	stopTracing, err := tracing.Setup(tracing.Config{Service: "kvserver", Exporter: traceExporter, Endpoint: traceEndpoint, SampleRatio: traceSampleRatio})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %v", err)
+	}
+	defer stopTracing(context.Background())
+	if traceExporter != "" {
+		slog.Info("tracing enabled", "exporter", traceExporter, "sample_ratio", traceSampleRatio)
+	}
+	registerMetrics(s)
+	if metricsAddr != "" {
+		if err := serveMetrics(metricsAddr); err != nil {
+			return fmt.Errorf("failed to serve metrics: %v", err)
+		}
+	}
+	if authTokens != "" || authMTLS {
+		var tokens map[string]string
+		if authTokens != "" {
+			if tokens, err = loadTokens(authTokens); err != nil {
+				return fmt.Errorf("failed to load the tokens: %v", err)
+			}
+		}
+		if authMTLS && tlsClientCA == "" {
+			return errors.New("-auth_mtls needs -tls_client_ca")
+		}
+		s.auth = newAuthenticator(s, tokens, authMTLS, strings.Split(authAdmins, ","))
+		slog.Info("authentication enabled", "tokens", len(tokens), "client_certificates", authMTLS)
+	}
+	if rateLimit > 0 {
+		s.limits.clients = newRateLimiter(rateLimit, rateBurst)
+		slog.Info("rate limit enabled", "calls_per_sec", rateLimit, "burst", s.limits.clients.burst)
 	}
-	info := fmt.Sprintf("elapsed time: %s to recover fron %s", time.Since(start), datasetFile)
-	log.Printf(info)
+	if maxInFlight > 0 {
+		if maxQueue < 0 {
+			maxQueue = maxInFlight
+		}
+		s.limits.inFlight = newAdmission(maxInFlight, maxQueue, time.Duration(queueTimeoutMs)*time.Millisecond)
+		slog.Info("admission control enabled", "max_in_flight", maxInFlight, "max_queue", maxQueue)
+	}
+	if s.keys, err = loadKeyring(encryptionKeyFile, encryptionKeyEnv); err != nil {
+		return fmt.Errorf("failed to load the encryption keys: %v", err)
+	} else if s.keys != nil {
+		slog.Info("encryption at rest enabled", "keys", len(s.keys.aeads), "current_key", s.keys.current)
+	}
+	if err := s.recoverFrom(datasetFile); err != nil {
+		return fmt.Errorf("failed to recover: %v", err)
+	}
+	s.recoveryTime = time.Since(start)
+	slog.Info("recovered", "file", datasetFile, "keys", s.inMemoryCache.Count(), "duration", s.recoveryTime.String())
 
 	logFile, err := os.OpenFile("history.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to open history.log: %v", err)
+	}
 	defer logFile.Close()
 	s.logFile = logFile
+	if info, err := logFile.Stat(); err == nil {
+		s.walSize = info.Size()
+	}
+	s.walEpoch = time.Now().UnixNano()
 
 	maxMsgSize := 1024 * 1024 * 16
-	grpcServer := grpc.NewServer(
+	serverOptions := []grpc.ServerOption{
 		grpc.KeepaliveEnforcementPolicy(kaep),
 		grpc.KeepaliveParams(kasp),
 		grpc.MaxRecvMsgSize(maxMsgSize),
 		grpc.MaxSendMsgSize(maxMsgSize),
-	)
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(tracing.UnaryServerInterceptor, requestUnaryInterceptor, metricsUnaryInterceptor, unaryInterceptor(s.auth), limitUnaryInterceptor(&s.limits))),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(tracing.StreamServerInterceptor, requestStreamInterceptor, metricsStreamInterceptor, streamInterceptor(s.auth), limitStreamInterceptor(&s.limits))),
+	}
+	if tlsCert != "" {
+		certs, err := tlsconfig.NewReloader(tlsconfig.Files{CertFile: tlsCert, KeyFile: tlsKey, CAFile: tlsClientCA}, tlsconfig.DefaultReloadInterval)
+		if err != nil {
+			return fmt.Errorf("failed to load the TLS certificates: %v", err)
+		}
+		defer certs.Close()
+		serverTLS, err := certs.ServerConfig(tlsRequireClientCert)
+		if err != nil {
+			return fmt.Errorf("invalid TLS settings: %v", err)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(serverTLS)))
+		// peers are dialed with the certificate of the server as client certificate
+		peerDialOptions = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(certs.ClientConfig()))}
+		slog.Info("TLS enabled", "client_certificates", clientCertPolicy())
+	} else if tlsClientCA != "" || tlsRequireClientCert {
+		return errors.New("-tls_client_ca and -tls_require_client_cert need -tls_cert and -tls_key")
+	}
+	if peerToken != "" {
+		peerDialOptions = append(peerDialOptions, grpc.WithPerRPCCredentials(tokenCredentials{token: peerToken, secure: tlsCert != ""}))
+	}
+	grpcServer := grpc.NewServer(serverOptions...)
 
 	pb.RegisterKVStoreServer(grpcServer, s)
-	log.Printf("grpc server live successfully!\n")
+	pb.RegisterReplicaServer(grpcServer, s)
+	pb.RegisterAdminServer(grpcServer, s)
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer()) // probed by client pools
+	if peerList != "" {
+		s.peers = newPeerSet(advertise, strings.Split(peerList, ","))
+		s.members = newMembership(s)
+		s.replicas = newAntiEntropy(s, time.Duration(aeInterval)*time.Second)
+		if s.hints, err = newHintStore("hints.log", s.keys); err != nil {
+			return fmt.Errorf("failed to open hints.log: %v", err)
+		}
+		go s.replicas.run(s.members.subscribe())
+		go s.hints.run(s, s.members.subscribe())
+		go s.members.run()
+	}
+	slog.Info("grpc server live", "addr", lis.Addr().String(), "mode", mode)
+
+	served := make(chan error, 1)
+	go func() { served <- grpcServer.Serve(lis) }()
 
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	var expired <-chan time.Time
 	if mode == "test" {
-		start := time.Now()
-		go func() {
-			<-time.After(time.Duration(exp_time) * time.Second)
-			opsCount := s.opsCount
-			grpcServer.Stop()
-			// server start time, #total_sets done, #total_gets done, #total_getprefixes done
-			log.Printf("server start time: %s, #total_sets: %d, #total_gets: %d, #total_getprefixes: %d\n",
-				time.Since(start), opsCount[0], opsCount[1], opsCount[2])
-		}()
+		expired = time.After(time.Duration(exp_time) * time.Second)
 	}
-
-	if err = grpcServer.Serve(lis); err != nil {
-		log.Printf("server has shut down: %v", err)
+	live := time.Now()
+	select {
+	case err := <-served:
+		return fmt.Errorf("server has shut down: %v", err)
+	case sig := <-signals:
+		slog.Info("shutting down", "signal", sig.String())
+	case <-expired:
+	}
+	signal.Stop(signals) // a second signal kills the server at once
+	if err := s.shutdown(grpcServer, time.Duration(shutdownTimeout)*time.Second, snapshotOnExit); err != nil {
+		return fmt.Errorf("failed to shut down cleanly: %v", err)
 	}
+	if mode == "test" {
+		// server start time, #total_sets done, #total_gets done, #total_getprefixes done
+		slog.Info("test done", "uptime", time.Since(live).String(), "total_sets", atomic.LoadInt64(&s.opsCount[countSet]),
+			"total_gets", atomic.LoadInt64(&s.opsCount[countGet]), "total_getprefixes", atomic.LoadInt64(&s.opsCount[countGetPrefix]))
+	}
+	return nil
+}
 
+func clientCertPolicy() string {
+	switch {
+	case tlsRequireClientCert:
+		return "required"
+	case tlsClientCA != "":
+		return "verified if given"
+	}
+	return "ignored"
 }
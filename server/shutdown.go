@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// snapshotFile holds the cache written on the last shutdown with
+// -snapshot_on_exit. Every start removes it before the log may change, so
+// while it exists it covers every record of history.log.
+const snapshotFile = "snapshot.json"
+
+// snapshotUsable tells whether the snapshot is sealed as the compaction would
+// seal the log, else a rotated key or a change of encryption needs the log
+// compacted again
+func (s *ServerMgr) snapshotUsable(snapshot string) bool {
+	f, err := os.Open(snapshot)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 64)
+	n, _ := io.ReadFull(f, head)
+	_, data, _ := strings.Cut(string(head[:n]), "\n") // after the timestamp
+	data = strings.TrimLeft(data, " \n")
+	if s.keys == nil {
+		return !strings.HasPrefix(data, string(sealedMark))
+	}
+	return strings.HasPrefix(data, string(sealedMark)+s.keys.current+":")
+}
+
+// recoverFrom fills the cache from the snapshot of the last shutdown when it
+// can, else by replaying and compacting the log, then removes the snapshot
+func (s *ServerMgr) recoverFrom(filename string) error {
+	if filename == "history.log" && s.snapshotUsable(snapshotFile) {
+		err := s.LoadFromSnapshot(snapshotFile)
+		if err == nil {
+			return removeSnapshot()
+		}
+		// the versions of the log win over what the snapshot loaded so far
+		slog.Warn("snapshot unreadable, replaying the log", "file", snapshotFile, "err", err)
+	}
+	if _, err := os.Stat(filename); err == nil {
+		if err := s.LoadFromHistoryLog(filename); err != nil {
+			return err
+		}
+	}
+	return removeSnapshot()
+}
+
+// removeSnapshot removes the snapshot for good before the log changes
+func removeSnapshot() error {
+	if err := os.Remove(snapshotFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return syncDir()
+}
+
+// syncDir makes the renames and removals of files in the working directory
+// durable
+func syncDir() error {
+	dir, err := os.Open(".")
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// stopServing stops accepting connections and waits for the calls in flight
+// until timeout, then cancels those left
+func stopServing(grpcServer *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Warn("calls still in flight after the shutdown timeout, cancelling them", "timeout", timeout.String())
+		grpcServer.Stop()
+		<-done
+	}
+}
+
+// shutdown stops the server: streams end, calls in flight finish, the log is
+// synced and closed, then the final snapshot is written if asked for. Writes
+// of background work after that fail on the closed log.
+func (s *ServerMgr) shutdown(grpcServer *grpc.Server, timeout time.Duration, snapshot bool) error {
+	start := time.Now()
+	close(s.stopping)
+	stopServing(grpcServer, timeout)
+
+	s.logLock.Lock()
+	err := s.logFile.Sync()
+	if cerr := s.logFile.Close(); err == nil {
+		err = cerr
+	}
+	s.logLock.Unlock()
+	if err != nil {
+		return err
+	}
+	if snapshot {
+		if err := s.SnapShot(snapshotFile); err != nil {
+			return err
+		}
+		slog.Info("final snapshot written", "file", snapshotFile, "keys", s.inMemoryCache.Count())
+	}
+	slog.Info("server stopped", "duration", time.Since(start).String())
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+)
+
+// Stats reports what the server did since it started. The key count and data
+// bytes come from the accounting of the namespaces, so they leave out
+// tombstones and the keys of the server.
+func (s *ServerMgr) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsResponse, error) {
+	res := &pb.StatsResponse{
+		StartTime:    s.startTime.UnixNano(),
+		UptimeMs:     int64(time.Since(s.startTime) / time.Millisecond),
+		RecoveryMs:   int64(s.recoveryTime / time.Millisecond),
+		Sets:         atomic.LoadInt64(&s.opsCount[countSet]),
+		Gets:         atomic.LoadInt64(&s.opsCount[countGet]),
+		GetPrefixes:  atomic.LoadInt64(&s.opsCount[countGetPrefix]),
+		Deletes:      atomic.LoadInt64(&s.opsCount[countDelete]),
+		Scans:        atomic.LoadInt64(&s.opsCount[countScan]),
+		Watches:      atomic.LoadInt64(&s.opsCount[countWatch]),
+		WalSize:      atomic.LoadInt64(&s.walSize),
+		LastSnapTime: atomic.LoadInt64(&s.lastSnapTime),
+		Revision:     atomic.LoadInt64(&s.revision),
+	}
+	s.namespaces.lock.RLock()
+	for _, ns := range s.namespaces.byName {
+		res.Keys += atomic.LoadInt64(&ns.keys)
+		res.DataBytes += atomic.LoadInt64(&ns.bytes)
+	}
+	s.namespaces.lock.RUnlock()
+	return res, nil
+}
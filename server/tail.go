@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const tailHeartbeat = time.Second
+
+// walAppended returns a channel closed on the next write-ahead log append
+func walAppended(s *ServerMgr) <-chan struct{} {
+	s.logLock.Lock()
+	defer s.logLock.Unlock()
+	return s.walSignal
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TailLog streams the write-ahead log from the requested position and keeps
+// following it until the caller goes away. A position from an older epoch
+// restarts from the beginning of the log, which holds every key.
+func (s *ServerMgr) TailLog(req *pb.TailLogRequest, stream pb.Replica_TailLogServer) error {
+	offset := req.GetOffset()
+	if req.GetEpoch() != s.walEpoch || offset < 0 || offset > atomic.LoadInt64(&s.walSize) {
+		offset = 0
+	}
+	file, err := os.Open(s.logFile.Name())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(file, 1024*1024)
+
+	lastSent := int64(-1)
+	for {
+		appended := walAppended(s)
+		line, err := reader.ReadString('\n')
+		if err == nil {
+			offset += int64(len(line))
+			key, entry, ok, err := s.readLogLine(line)
+			if err != nil {
+				return status.Errorf(codes.DataLoss, "history.log at %d: %v", offset, err)
+			}
+			if !ok || !hasAnyPrefix(key, req.GetPrefixes()) {
+				continue
+			}
+			record := &pb.LogRecord{Epoch: s.walEpoch, Offset: offset, LogSize: atomic.LoadInt64(&s.walSize),
+				Entry: toEntry(key, entry)}
+			if err := stream.Send(record); err != nil {
+				return err
+			}
+			lastSent = offset
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		if len(line) > 0 {
+			// the record is still being written, read it again once it is complete
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				return err
+			}
+			reader.Reset(file)
+		}
+
+		// caught up, tell the caller how far it got even if every record was filtered out
+		if offset != lastSent {
+			if err := stream.Send(&pb.LogRecord{Epoch: s.walEpoch, Offset: offset, LogSize: atomic.LoadInt64(&s.walSize)}); err != nil {
+				return err
+			}
+			lastSent = offset
+		}
+		select {
+		case <-appended:
+		case <-time.After(tailHeartbeat):
+			lastSent = -1
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
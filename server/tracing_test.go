@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// tracedServer serves a standalone server logging to a temporary directory
+// over an in-memory connection, with the tracing and request id interceptors
+// of run, and returns a traced client and a func flushing and returning the
+// spans recorded so far
+func tracedServer(t *testing.T) (pb.KVStoreClient, func() tracetest.SpanStubs) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.Install("kvserver", exporter, 1)
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	s := NewServerMgr("normal", "localhost:0")
+	logFile, err := os.OpenFile(filepath.Join(t.TempDir(), "history.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logFile.Close() })
+	s.logFile = logFile
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(tracing.UnaryServerInterceptor, requestUnaryInterceptor)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(tracing.StreamServerInterceptor, requestStreamInterceptor)),
+	)
+	pb.RegisterKVStoreServer(grpcServer, s)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor),
+		grpc.WithStreamInterceptor(tracing.StreamClientInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewKVStoreClient(conn), func() tracetest.SpanStubs {
+		if err := tp.ForceFlush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return exporter.GetSpans()
+	}
+}
+
+// findSpan returns the only span of the name and kind
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string, kind trace.SpanKind) tracetest.SpanStub {
+	t.Helper()
+	var found []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == name && span.SpanKind == kind {
+			found = append(found, span)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("found %d %s spans named %q, want 1", len(found), kind, name)
+	}
+	return found[0]
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func checkParent(t *testing.T, child, parent tracetest.SpanStub) {
+	t.Helper()
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() || child.SpanContext.TraceID() != parent.SpanContext.TraceID() {
+		t.Errorf("%s span is not a child of the %s span", child.Name, parent.Name)
+	}
+}
+
+func TestWriteSpans(t *testing.T) {
+	client, spans := tracedServer(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "req-42")
+	if _, err := client.Set(ctx, &pb.SetRequest{Key: "k", Value: "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := spans()
+	call := findSpan(t, got, "kv.KVStore/Set", trace.SpanKindClient)
+	server := findSpan(t, got, "kv.KVStore/Set", trace.SpanKindServer)
+	appendSpan := findSpan(t, got, "wal.append", trace.SpanKindInternal)
+	fsync := findSpan(t, got, "wal.fsync", trace.SpanKindInternal)
+	update := findSpan(t, got, "cache.update", trace.SpanKindInternal)
+	checkParent(t, server, call)
+	checkParent(t, appendSpan, server)
+	checkParent(t, fsync, appendSpan)
+	checkParent(t, update, server)
+	if id := spanAttr(server, "request.id").AsString(); id != "req-42" {
+		t.Errorf("server span request.id %q, want the id sent by the client", id)
+	}
+	if n := spanAttr(appendSpan, "wal.bytes").AsInt64(); n == 0 {
+		t.Error("wal.append span without wal.bytes")
+	}
+}
+
+func TestReadSpans(t *testing.T) {
+	client, spans := tracedServer(t)
+	if _, err := client.Set(context.Background(), &pb.SetRequest{Key: "k1", Value: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	var header metadata.MD
+	if _, err := client.GetPrefix(context.Background(), &pb.GetPrefixRequest{Key: "k"}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.Scan(context.Background(), &pb.ScanRequest{Prefix: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := spans()
+	getPrefix := findSpan(t, got, "kv.KVStore/GetPrefix", trace.SpanKindServer)
+	scan := findSpan(t, got, "kv.KVStore/Scan", trace.SpanKindServer)
+	var scans []tracetest.SpanStub
+	for _, span := range got {
+		if span.Name == "cache.prefix_scan" {
+			scans = append(scans, span)
+		}
+	}
+	if len(scans) != 2 {
+		t.Fatalf("found %d cache.prefix_scan spans, want one for GetPrefix and one for Scan", len(scans))
+	}
+	checkParent(t, scans[0], getPrefix)
+	checkParent(t, scans[1], scan)
+
+	// without an id from the caller the server makes one, sends it back and tags the span with it
+	sent := header.Get(requestIDKey)
+	if id := spanAttr(getPrefix, "request.id").AsString(); len(sent) != 1 || id != sent[0] {
+		t.Errorf("server span request.id %q, response header %q", id, sent)
+	}
+	if id := spanAttr(scan, "request.id").AsString(); id == "" {
+		t.Error("stream server span without request.id")
+	}
+}
@@ -1,42 +1,283 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cmap "github.com/orcaman/concurrent-map"
+	"github.com/ss87021456/gRPC-KVStore/bulk"
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"github.com/ss87021456/gRPC-KVStore/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-func writeAheadLog(s *ServerMgr, key string, value string) error {
+func writeAheadLog(ctx context.Context, s *ServerMgr, key string, entry cacheEntry) error {
+	ctx, span := tracing.Start(ctx, "wal.append")
+	defer span.End()
 	s.logLock.Lock()
 	defer s.logLock.Unlock()
 
-	outStr := fmt.Sprintf("%v,%s,%s,done\n", time.Now().Unix(), key, value) // done for the checksum
+	outStr := s.logLine(key, entry)
+	span.SetAttributes(attribute.Int("wal.bytes", len(outStr)))
 	var err error
 	if _, err = s.logFile.WriteString(outStr); err != nil {
-		log.Println(err)
+		logger(ctx).Error("write to history.log failed", "err", err)
+		span.SetStatus(otelcodes.Error, err.Error())
 		return err
 	}
-	return s.logFile.Sync() // ensure write to stable disk
+	_, fsync := tracing.Start(ctx, "wal.fsync")
+	start := time.Now()
+	err = s.logFile.Sync() // ensure write to stable disk
+	fsync.End()
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+		return err
+	}
+	walFsyncDuration.Observe(time.Since(start).Seconds())
+	atomic.AddInt64(&s.walSize, int64(len(outStr)))
+	close(s.walSignal)
+	s.walSignal = make(chan struct{})
+	return nil
+}
+
+// formatLogRecord lays out a write-ahead log line in the log format of the
+// bulk package, whose trailing done or deleted tells complete records apart
+func formatLogRecord(key string, entry cacheEntry) string {
+	return bulk.FormatLogRecord(bulk.Record{Key: key, Value: entry.Value, Version: entry.Version, Deleted: entry.Deleted, Replicas: entry.Replicas})
+}
+
+func parseLogRecord(line string) (string, cacheEntry, bool) {
+	r, ok := bulk.ParseLogRecord(line)
+	return r.Key, cacheEntry{Value: r.Value, Version: r.Version, Deleted: r.Deleted, Replicas: r.Replicas}, ok
+}
+
+// logLine is the log record of the entry, sealed with the current key when
+// encryption is on
+func (s *ServerMgr) logLine(key string, entry cacheEntry) string {
+	if s.keys == nil {
+		return formatLogRecord(key, entry)
+	}
+	return s.keys.seal(strings.TrimSuffix(formatLogRecord(key, entry), "\n")) + "\n"
+}
+
+// readLogLine parses a log line, opening it first if it is sealed. A record
+// cut short by a crash is not ok, a line that cannot be opened is an error.
+func (s *ServerMgr) readLogLine(line string) (string, cacheEntry, bool, error) {
+	plain, err := s.keys.open(line)
+	if err != nil {
+		return "", cacheEntry{}, false, err
+	}
+	key, entry, ok := parseLogRecord(plain)
+	return key, entry, ok, nil
+}
+
+func toEntry(key string, entry cacheEntry) *pb.Entry {
+	return &pb.Entry{Key: key, Value: entry.Value, Version: entry.Version, Deleted: entry.Deleted, Replicas: entry.Replicas}
+}
+
+func fromEntry(e *pb.Entry) cacheEntry {
+	return cacheEntry{Value: e.GetValue(), Version: e.GetVersion(), Deleted: e.GetDeleted(), Replicas: e.GetReplicas()}
 }
 
 func getHelper(s *ServerMgr, key string) (string, error) {
 	// Retrieve item from map.
-	if tmp, ok := s.inMemoryCache.Get(key); ok {
-		return tmp.(string), nil
+	if tmp, ok := s.inMemoryCache.Get(key); ok && !tmp.(cacheEntry).Deleted {
+		return tmp.(cacheEntry).Value, nil
 	}
-	return "", fmt.Errorf("key: %s not exist", key)
+	return "", status.Errorf(codes.NotFound, "key: %s not exist", displayKey(key))
 }
 
-func setHelper(s *ServerMgr, key string, value string) {
-	s.inMemoryCache.Set(key, value)
+// writeHelper stamps a local set or delete with its version and stores it, on
+// this server only or on the quorum the request asked for
+func writeHelper(ctx context.Context, s *ServerMgr, key string, entry cacheEntry, q *pb.Quorum) error {
+	if q.GetN() > 0 {
+		if err := checkQuorum(s, q); err != nil {
+			return err
+		}
+		return quorumSet(ctx, s, key, entry, q)
+	}
+	entry.Version = nextVersion(s, key)
+	if err := writeAheadLog(ctx, s, key, entry); err != nil {
+		return err
+	}
+	tracedSet(ctx, s, key, entry)
+	return nil
+}
+
+func quorumGetHelper(s *ServerMgr, key string, q *pb.Quorum) (string, error) {
+	if err := checkQuorum(s, q); err != nil {
+		return "", err
+	}
+	entry, found, err := quorumGet(s, key, q)
+	if err != nil {
+		return "", err
+	}
+	if !found || entry.Deleted {
+		return "", status.Errorf(codes.NotFound, "key: %s not exist", displayKey(key))
+	}
+	return entry.Value, nil
+}
+
+// setHelper stores the entry unless the cache already holds a newer version
+// of the key, and reports whether it was stored. Replaying writes in log order
+// keeps the last one, since equal versions also overwrite.
+func setHelper(s *ServerMgr, key string, entry cacheEntry) bool {
+	applied, existed := false, false
+	var old cacheEntry
+	s.inMemoryCache.Upsert(key, entry, func(exist bool, valueInMap interface{}, newValue interface{}) interface{} {
+		if exist && valueInMap.(cacheEntry).Version > entry.Version {
+			return valueInMap
+		}
+		if exist {
+			old, existed = valueInMap.(cacheEntry), true
+		}
+		applied = true
+		return newValue
+	})
+	if applied {
+		atomic.AddInt64(&s.revision, 1)
+		for last := atomic.LoadInt64(&s.lastWrite); entry.Version > last; last = atomic.LoadInt64(&s.lastWrite) {
+			if atomic.CompareAndSwapInt64(&s.lastWrite, last, entry.Version) {
+				break
+			}
+		}
+		s.namespaces.account(key, old, existed, entry)
+		s.watchers.publish(key, entry)
+	}
+	return applied
 }
 
-func prefixHelper(s *ServerMgr, prefix string) []string {
+// tracedSet is setHelper in a span of its own, for the writes of a call
+func tracedSet(ctx context.Context, s *ServerMgr, key string, entry cacheEntry) bool {
+	_, span := tracing.Start(ctx, "cache.update")
+	defer span.End()
+	return setHelper(s, key, entry)
+}
+
+// newerEntry tells whether a wins over b: the later version, and for equal
+// versions written on two replicas the tombstone, then the greater value, so
+// that every replica settles on the same entry
+func newerEntry(a, b cacheEntry) bool {
+	if a.Version != b.Version {
+		return a.Version > b.Version
+	}
+	if a.Deleted != b.Deleted {
+		return a.Deleted
+	}
+	return a.Value > b.Value
+}
+
+// applyEntry logs and stores a version of the key written elsewhere, e.g. on
+// a replica, and reports whether it was newer than the version held here.
+func applyEntry(ctx context.Context, s *ServerMgr, key string, entry cacheEntry) (bool, error) {
+	if tmp, ok := s.inMemoryCache.Get(key); ok && !newerEntry(entry, tmp.(cacheEntry)) {
+		return false, nil
+	}
+	if err := writeAheadLog(ctx, s, key, entry); err != nil {
+		return false, err
+	}
+	return tracedSet(ctx, s, key, entry), nil
+}
+
+// nextVersion stamps a local write so that it is ordered after whatever version
+// of the key this server already holds, even if the clock went backwards.
+func nextVersion(s *ServerMgr, key string) int64 {
+	version := time.Now().UnixNano()
+	if tmp, ok := s.inMemoryCache.Get(key); ok && tmp.(cacheEntry).Version >= version {
+		version = tmp.(cacheEntry).Version + 1
+	}
+	return version
+}
+
+// forwardedKey marks a read forwarded by a peer, so that it is not forwarded
+// again
+const forwardedKey = "x-forwarded-by"
+
+// readState is how a read is served: the version of the latest write this
+// server applied and its lag, and the peer to forward the read to, "" to
+// serve it here
+type readState struct {
+	revision    int64
+	stalenessMs int64
+	forwardTo   string
+	behind      string // why the read is forwarded
+}
+
+// checkReadOptions decides whether a read can be served by this server. The
+// revision of a replica is the version of the latest write it applied, which
+// every replica gives the same write, and its staleness how far that is behind
+// the freshest live peer as of the last heartbeats, 0 without peers. `any`
+// reads are always served locally. A linearizable read is served locally only
+// by a replica no peer is known to be ahead of, a bounded-staleness one only
+// if the replica lags at most max_staleness_ms (0 for no bound) and reached
+// min_revision. Other reads go to the freshest peer, unless they were
+// forwarded already or that peer is not fresh enough either.
+func checkReadOptions(ctx context.Context, s *ServerMgr, opts *pb.ReadOptions) (readState, error) {
+	st := readState{revision: atomic.LoadInt64(&s.lastWrite)}
+	freshest, peerRevision := s.members.freshest()
+	lag := time.Duration(peerRevision - st.revision)
+	if lag > 0 {
+		st.stalenessMs = lag.Milliseconds()
+	}
+	if opts.GetMaxStalenessMs() < 0 || opts.GetMinRevision() < 0 {
+		return st, status.Errorf(codes.InvalidArgument, "staleness bound must not be negative")
+	}
+	want := peerRevision // what the peer must have applied to serve the read
+	switch opts.GetConsistency() {
+	case pb.ReadConsistency_ANY:
+		return st, nil
+	case pb.ReadConsistency_LINEARIZABLE:
+		if lag > 0 {
+			st.behind = fmt.Sprintf("replica is %v behind its peers", lag)
+		}
+	case pb.ReadConsistency_BOUNDED_STALENESS:
+		if bound := opts.GetMaxStalenessMs(); bound > 0 && st.stalenessMs > bound {
+			st.behind = fmt.Sprintf("replica is %dms behind its peers, over the bound of %dms", st.stalenessMs, bound)
+		}
+		if min := opts.GetMinRevision(); min > st.revision {
+			st.behind = fmt.Sprintf("replica is at revision %d, behind requested revision %d", st.revision, min)
+			want = min
+		}
+	default:
+		return st, status.Errorf(codes.InvalidArgument, "unknown read consistency %v", opts.GetConsistency())
+	}
+	if st.behind == "" {
+		return st, nil
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); (ok && len(md.Get(forwardedKey)) > 0) || freshest == "" || peerRevision < want {
+		return st, status.Error(codes.FailedPrecondition, st.behind)
+	}
+	st.forwardTo = freshest
+	return st, nil
+}
+
+// forwardRead calls the peer of st with the read this server is too far
+// behind to serve
+func forwardRead(ctx context.Context, s *ServerMgr, st readState, call func(context.Context, pb.KVStoreClient) error) error {
+	client, err := s.peers.kvClient(st.forwardTo)
+	if err == nil {
+		logger(ctx).Debug("forwarding read", "peer", st.forwardTo, "reason", st.behind)
+		err = call(metadata.AppendToOutgoingContext(ctx, forwardedKey, s.peers.self), client)
+	}
+	if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+		return status.Errorf(codes.FailedPrecondition, "%s and %s did not answer: %v", st.behind, st.forwardTo, err)
+	}
+	return err
+}
+
+func prefixHelper(ctx context.Context, s *ServerMgr, prefix string) []string {
+	_, span := tracing.Start(ctx, "cache.prefix_scan")
+	defer span.End()
 	returnList := []string{}
 	in := s.inMemoryCache.Iter()
 	workers := make([]<-chan string, runtime.NumCPU())
@@ -48,6 +289,7 @@ func prefixHelper(s *ServerMgr, prefix string) []string {
 	for res := range merge(workers...) {
 		returnList = append(returnList, res)
 	}
+	span.SetAttributes(attribute.Int("cache.matches", len(returnList)))
 	return returnList
 }
 
@@ -55,8 +297,8 @@ func checkTuples(items <-chan cmap.Tuple, prefix string) <-chan string {
 	out := make(chan string) // maybe buffer will be helpful
 	go func() {
 		for item := range items {
-			if strings.HasPrefix(item.Key, prefix) {
-				out <- item.Val.(string)
+			if entry := item.Val.(cacheEntry); !entry.Deleted && visible(item.Key, prefix) {
+				out <- entry.Value
 			}
 		}
 		close(out)
@@ -87,9 +329,9 @@ func merge(cs ...<-chan string) <-chan string {
 }
 
 func showCache(s *ServerMgr) {
-	fmt.Println("========= showCache ========")
 	for m := range s.inMemoryCache.Iter() {
-		log.Printf("key %s value %s", m.Key, m.Val)
+		if entry := m.Val.(cacheEntry); !entry.Deleted {
+			slog.Debug("cache entry", "key", m.Key, "value", entry.Value)
+		}
 	}
-	fmt.Println("========= showCache ========")
 }
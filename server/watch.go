@@ -0,0 +1,119 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/ss87021456/gRPC-KVStore/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchBuffer is how many changes a watcher may fall behind before its
+// stream is ended, so a slow watcher never holds up writes
+const watchBuffer = 4096
+
+type watcher struct {
+	prefixes []string // stored keys, in the namespace of the watcher
+	strip    string   // namespace prefix taken off the keys sent
+	events   chan *pb.Entry
+	overflow chan struct{}
+}
+
+// watchHub fans every stored change out to the open Watch streams
+type watchHub struct {
+	lock     sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watchers: make(map[*watcher]struct{})}
+}
+
+func (h *watchHub) add(prefixes []string, ns *nsState) *watcher {
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	w := &watcher{strip: ns.prefix, events: make(chan *pb.Entry, watchBuffer), overflow: make(chan struct{})}
+	for _, prefix := range prefixes {
+		w.prefixes = append(w.prefixes, ns.prefix+prefix)
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.watchers[w] = struct{}{}
+	return w
+}
+
+func (h *watchHub) remove(w *watcher) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.watchers, w)
+}
+
+// publish hands the change to every interested watcher. A watcher with a full
+// buffer is dropped, it would otherwise miss the change without knowing.
+func (h *watchHub) publish(key string, entry cacheEntry) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.watchers) == 0 {
+		return
+	}
+	for w := range h.watchers {
+		if !w.matches(key) {
+			continue
+		}
+		select {
+		case w.events <- toEntry(key[len(w.strip):], entry):
+		default:
+			close(w.overflow)
+			delete(h.watchers, w)
+		}
+	}
+}
+
+func (w *watcher) matches(key string) bool {
+	for _, prefix := range w.prefixes {
+		if visible(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch streams the changes of the requested prefixes until the caller goes
+// away, with a heartbeat every tailHeartbeat when nothing changes.
+func (s *ServerMgr) Watch(req *pb.WatchRequest, stream pb.KVStore_WatchServer) error {
+	atomic.AddInt64(&s.opsCount[countWatch], 1)
+	ns, err := s.namespaces.enter(stream.Context(), req.GetNamespace(), opRead)
+	if err != nil {
+		return err
+	}
+	w := s.watchers.add(req.GetPrefixes(), ns)
+	defer s.watchers.remove(w)
+
+	// tell the caller the stream is up, changes after this point are delivered
+	if err := stream.Send(&pb.WatchEvent{Revision: atomic.LoadInt64(&s.revision)}); err != nil {
+		return err
+	}
+	heartbeat := time.NewTicker(tailHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var event *pb.WatchEvent
+		select {
+		case e := <-w.events:
+			event = &pb.WatchEvent{Entry: e, Revision: atomic.LoadInt64(&s.revision)}
+		case <-heartbeat.C:
+			event = &pb.WatchEvent{Revision: atomic.LoadInt64(&s.revision)}
+		case <-w.overflow:
+			return status.Error(codes.ResourceExhausted, "watcher fell too far behind")
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+}
@@ -12,10 +12,20 @@ make build
 CLIENT_NUM=10
 
 ./server/kvserver -mode test -exp_time 10 -p 8888 &
+SERVER_PID=$!
+sleep 1
 
+CLIENT_PIDS=()
 for i in `eval echo {1..$CLIENT_NUM}`
 do
     ./client/kvclient -mode test -exp_time 5 -p 8888 &
+    CLIENT_PIDS+=($!)
 done
+wait "${CLIENT_PIDS[@]}"
+
+# print the stats of the server, then stop it
+./client/kvclient -mode stats -p 8888
+kill $SERVER_PID
+wait $SERVER_PID 2>/dev/null
 
 exit 0
\ No newline at end of file
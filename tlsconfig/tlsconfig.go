@@ -0,0 +1,204 @@
+// Package tlsconfig builds the TLS settings of kvservers and their clients
+// from PEM files, and reloads the files when they change so certificates can
+// be renewed without a restart.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is how often the files are checked for changes.
+const DefaultReloadInterval = 5 * time.Second
+
+// Files names the PEM files of one side of a connection. CertFile and KeyFile
+// are its own certificate, required on a server and optional on a client.
+// CAFile holds the certificates trusted for the other side: the client
+// certificates on a server, the server certificates on a client, where the
+// system roots are used if it is empty.
+type Files struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// Reloader keeps the certificates of Files, reloaded when a file changes.
+type Reloader struct {
+	files Files
+
+	lock    sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewReloader loads the files and checks them for changes every interval
+// until Close, keeping the previous certificates if a changed file is invalid.
+func NewReloader(files Files, interval time.Duration) (*Reloader, error) {
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, errors.New("tlsconfig: a certificate needs both a cert and a key file")
+	}
+	r := &Reloader{files: files, modTime: make(map[string]time.Time), done: make(chan struct{})}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go r.watch(interval)
+	}
+	return r, nil
+}
+
+// Close stops checking the files.
+func (r *Reloader) Close() {
+	r.once.Do(func() { close(r.done) })
+}
+
+func (r *Reloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if reloaded, err := r.reload(); err != nil {
+				log.Printf("tlsconfig: keeping the previous certificates: %v", err)
+			} else if reloaded {
+				log.Printf("tlsconfig: reloaded %s", r.describe())
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+func (r *Reloader) describe() string {
+	s := ""
+	for _, name := range []string{r.files.CertFile, r.files.CAFile} {
+		if name != "" {
+			if s != "" {
+				s += " and "
+			}
+			s += name
+		}
+	}
+	return s
+}
+
+// reload loads the files again if any of them changed since the last load
+func (r *Reloader) reload() (bool, error) {
+	modTime := make(map[string]time.Time)
+	changed := false
+	for _, name := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return false, err
+		}
+		modTime[name] = info.ModTime()
+		r.lock.RLock()
+		if last, ok := r.modTime[name]; !ok || !last.Equal(info.ModTime()) {
+			changed = true
+		}
+		r.lock.RUnlock()
+	}
+	if !changed {
+		return false, nil
+	}
+
+	var cert *tls.Certificate
+	if r.files.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+		if err != nil {
+			return false, err
+		}
+		cert = &c
+	}
+	var pool *x509.CertPool
+	if r.files.CAFile != "" {
+		pem, err := ioutil.ReadFile(r.files.CAFile)
+		if err != nil {
+			return false, err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("no certificate found in %s", r.files.CAFile)
+		}
+	}
+	r.lock.Lock()
+	r.cert, r.pool, r.modTime = cert, pool, modTime
+	r.lock.Unlock()
+	return true, nil
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.cert, r.pool
+}
+
+// ServerConfig is the TLS configuration of a server. With a CAFile, client
+// certificates signed by it are verified, and required if requireClientCert.
+func (r *Reloader) ServerConfig(requireClientCert bool) (*tls.Config, error) {
+	if r.files.CertFile == "" {
+		return nil, errors.New("tlsconfig: a server needs a certificate")
+	}
+	if requireClientCert && r.files.CAFile == "" {
+		return nil, errors.New("tlsconfig: requiring client certificates needs a CA file")
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// a config per handshake picks up reloaded certificates
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			cfg := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{*cert}, NextProtos: []string{"h2"}}
+			if pool != nil {
+				cfg.ClientCAs = pool
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+				if requireClientCert {
+					cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+			return cfg, nil
+		},
+	}, nil
+}
+
+// ClientConfig is the TLS configuration of a client, presenting the
+// certificate of the files if any.
+func (r *Reloader) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert, _ := r.current(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		// the server certificate is verified below instead, against the
+		// CA pool of the moment rather than the one at dial time
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			_, pool := r.current()
+			opts := x509.VerifyOptions{DNSName: cs.ServerName, Roots: pool, Intermediates: x509.NewCertPool()}
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tlsconfig: server sent no certificate")
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
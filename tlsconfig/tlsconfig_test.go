@@ -0,0 +1,286 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// authority signs the certificates of a test
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+var serial int64
+
+func newTemplate(cn string) *x509.Certificate {
+	serial++
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
+
+func newAuthority(t *testing.T, cn string) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := newTemplate(cn)
+	tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	tmpl.KeyUsage = x509.KeyUsageCertSign
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of cn, valid for localhost
+func (a *authority) issue(t *testing.T, cn string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := newTemplate(cn)
+	tmpl.DNSNames = []string{"localhost"}
+	tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes the file with a modification time of its own, so that a
+// rewrite within the resolution of the file system is still seen as a change
+func writeFile(t *testing.T, name string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFiles writes the certificate and key of cn signed by ca, and the
+// certificate of trusted as CA file, under dir
+func writeFiles(t *testing.T, dir string, ca *authority, cn string, trusted *authority, modTime time.Time) Files {
+	t.Helper()
+	files := Files{CertFile: filepath.Join(dir, cn+".crt"), KeyFile: filepath.Join(dir, cn+".key"), CAFile: filepath.Join(dir, cn+"-ca.crt")}
+	certPEM, keyPEM := ca.issue(t, cn)
+	writeFile(t, files.CertFile, certPEM, modTime)
+	writeFile(t, files.KeyFile, keyPEM, modTime)
+	writeFile(t, files.CAFile, trusted.pem, modTime)
+	return files
+}
+
+func newReloader(t *testing.T, files Files, interval time.Duration) *Reloader {
+	t.Helper()
+	r, err := NewReloader(files, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+	return r
+}
+
+// handshake connects the client to the server over loopback and returns the
+// state seen by the client, and the errors of both sides
+func handshake(t *testing.T, server, client *tls.Config) (tls.ConnectionState, error, error) {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		err = conn.(*tls.Conn).Handshake()
+		if err == nil {
+			_, err = conn.Write([]byte{1})
+		}
+		serverErr <- err
+	}()
+
+	var state tls.ConnectionState
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", ln.Addr().String(), client)
+	if err == nil {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		// with TLS 1.3 a rejected client certificate only shows on the first read
+		_, err = conn.Read(make([]byte, 1))
+		state = conn.ConnectionState()
+		conn.Close()
+	}
+	return state, <-serverErr, err
+}
+
+func TestHandshake(t *testing.T) {
+	dir := t.TempDir()
+	ca := newAuthority(t, "ca")
+	server := newReloader(t, writeFiles(t, dir, ca, "server", ca, time.Now()), 0)
+	client := newReloader(t, Files{CAFile: filepath.Join(dir, "server-ca.crt")}, 0)
+
+	serverTLS, err := server.ServerConfig(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, serverErr, clientErr := handshake(t, serverTLS, client.ClientConfig())
+	if serverErr != nil || clientErr != nil {
+		t.Fatalf("handshake failed: server %v, client %v", serverErr, clientErr)
+	}
+	if cn := state.PeerCertificates[0].Subject.CommonName; cn != "server" {
+		t.Errorf("server certificate is %q, want server", cn)
+	}
+}
+
+func TestHandshakeUntrustedServer(t *testing.T) {
+	dir := t.TempDir()
+	ca, other := newAuthority(t, "ca"), newAuthority(t, "other")
+	server := newReloader(t, writeFiles(t, dir, ca, "server", ca, time.Now()), 0)
+	caFile := filepath.Join(dir, "other.crt")
+	writeFile(t, caFile, other.pem, time.Now())
+	client := newReloader(t, Files{CAFile: caFile}, 0)
+
+	serverTLS, err := server.ServerConfig(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, clientErr := handshake(t, serverTLS, client.ClientConfig()); clientErr == nil {
+		t.Fatal("client accepted a server certificate signed by an untrusted CA")
+	}
+}
+
+func TestRequireClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca := newAuthority(t, "ca")
+	server := newReloader(t, writeFiles(t, dir, ca, "server", ca, time.Now()), 0)
+	serverTLS, err := server.ServerConfig(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anonymous := newReloader(t, Files{CAFile: filepath.Join(dir, "server-ca.crt")}, 0)
+	_, serverErr, clientErr := handshake(t, serverTLS, anonymous.ClientConfig())
+	if serverErr == nil || clientErr == nil {
+		t.Fatalf("client without a certificate was accepted: server %v, client %v", serverErr, clientErr)
+	}
+
+	client := newReloader(t, writeFiles(t, dir, ca, "client", ca, time.Now()), 0)
+	if _, serverErr, clientErr := handshake(t, serverTLS, client.ClientConfig()); serverErr != nil || clientErr != nil {
+		t.Fatalf("client with a certificate was rejected: server %v, client %v", serverErr, clientErr)
+	}
+}
+
+func TestServerConfigNeedsFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := newAuthority(t, "ca")
+	files := writeFiles(t, dir, ca, "server", ca, time.Now())
+	if _, err := newReloader(t, Files{CAFile: files.CAFile}, 0).ServerConfig(false); err == nil {
+		t.Error("server config without a certificate")
+	}
+	if _, err := newReloader(t, Files{CertFile: files.CertFile, KeyFile: files.KeyFile}, 0).ServerConfig(true); err == nil {
+		t.Error("client certificates required without a CA file")
+	}
+	if _, err := NewReloader(Files{CertFile: files.CertFile}, 0); err == nil {
+		t.Error("certificate without a key file")
+	}
+}
+
+func TestReloadRotated(t *testing.T) {
+	dir := t.TempDir()
+	oldCA, newCA := newAuthority(t, "old-ca"), newAuthority(t, "new-ca")
+	start := time.Now().Add(-time.Minute)
+	serverFiles := writeFiles(t, dir, oldCA, "server", oldCA, start)
+	clientFiles := writeFiles(t, dir, oldCA, "client", oldCA, start)
+	server := newReloader(t, serverFiles, 10*time.Millisecond)
+	client := newReloader(t, clientFiles, 10*time.Millisecond)
+	serverTLS, err := server.ServerConfig(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTLS := client.ClientConfig()
+	state, serverErr, clientErr := handshake(t, serverTLS, clientTLS)
+	if serverErr != nil || clientErr != nil {
+		t.Fatalf("handshake failed: server %v, client %v", serverErr, clientErr)
+	}
+	if issuer := state.PeerCertificates[0].Issuer.CommonName; issuer != "old-ca" {
+		t.Fatalf("server certificate issued by %q, want old-ca", issuer)
+	}
+
+	// both sides move to the new CA, the configs made before keep working
+	writeFiles(t, dir, newCA, "server", newCA, start.Add(time.Second))
+	writeFiles(t, dir, newCA, "client", newCA, start.Add(time.Second))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state, serverErr, clientErr = handshake(t, serverTLS, clientTLS)
+		if serverErr == nil && clientErr == nil && state.PeerCertificates[0].Issuer.CommonName == "new-ca" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated certificates not picked up: server %v, client %v", serverErr, clientErr)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// a client still holding the old certificate is no longer trusted
+	stale := newReloader(t, writeFiles(t, t.TempDir(), oldCA, "stale", newCA, start), 0)
+	if _, serverErr, _ := handshake(t, serverTLS, stale.ClientConfig()); serverErr == nil {
+		t.Fatal("server accepted a client certificate of the rotated out CA")
+	}
+}
+
+func TestReloadKeepsPreviousOnInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	ca := newAuthority(t, "ca")
+	start := time.Now().Add(-time.Minute)
+	files := writeFiles(t, dir, ca, "server", ca, start)
+	r := newReloader(t, files, 0)
+	before, _ := r.current()
+
+	writeFile(t, files.CertFile, []byte("not a certificate"), start.Add(time.Second))
+	if _, err := r.reload(); err == nil {
+		t.Fatal("invalid certificate loaded")
+	}
+	if after, _ := r.current(); after != before {
+		t.Error("previous certificate dropped after a failed reload")
+	}
+	if reloaded, err := r.reload(); err == nil || reloaded {
+		t.Errorf("invalid file not retried: reloaded %v, err %v", reloaded, err)
+	}
+}
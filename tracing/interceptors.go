@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// metadataCarrier lets the propagator read and write the trace context in
+// gRPC metadata, as a traceparent key
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// traced leaves out the health checks clients keep making
+func traced(fullMethod string) bool {
+	return !strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/")
+}
+
+// callAttributes names the call as the rpc semantic conventions do
+func callAttributes(fullMethod string) []attribute.KeyValue {
+	service, method := "unknown", strings.TrimPrefix(fullMethod, "/")
+	if i := strings.IndexByte(method, '/'); i >= 0 {
+		service, method = method[:i], method[i+1:]
+	}
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", method),
+	}
+}
+
+func startCall(ctx context.Context, fullMethod string, kind trace.SpanKind) (context.Context, trace.Span) {
+	attrs := callAttributes(fullMethod)
+	if p, ok := peer.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.String("net.peer.addr", p.Addr.String()))
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// endCall records the status of the call, io.EOF ending a stream is success
+func endCall(span trace.Span, err error) {
+	if err == io.EOF {
+		err = nil
+	}
+	s := status.Convert(err)
+	span.SetAttributes(attribute.Int64("rpc.grpc.status_code", int64(s.Code())))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, s.Message())
+	}
+	span.End()
+}
+
+// serverContext continues the trace of the caller found in the metadata
+func serverContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// clientContext adds the trace context of ctx to the outgoing metadata
+func clientContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// UnaryServerInterceptor traces every unary call as a server span.
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !traced(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, span := startCall(serverContext(ctx), info.FullMethod, trace.SpanKindServer)
+	res, err := handler(ctx, req)
+	endCall(span, err)
+	return res, err
+}
+
+// tracedServerStream hands the context holding the span to the handler
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerInterceptor traces every streaming call as a server span lasting
+// until the handler returns.
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !traced(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, span := startCall(serverContext(ss.Context()), info.FullMethod, trace.SpanKindServer)
+	err := handler(srv, tracedServerStream{ServerStream: ss, ctx: ctx})
+	endCall(span, err)
+	return err
+}
+
+// UnaryClientInterceptor traces every unary call as a client span, each
+// retry being a call of its own.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !traced(method) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	ctx, span := startCall(ctx, method, trace.SpanKindClient)
+	err := invoker(clientContext(ctx), method, req, reply, cc, opts...)
+	endCall(span, err)
+	return err
+}
+
+// tracedClientStream ends the span with the stream, when a receive fails or
+// the context of the call is done
+type tracedClientStream struct {
+	grpc.ClientStream
+	span trace.Span
+	once *sync.Once
+}
+
+func (s tracedClientStream) end(err error) {
+	s.once.Do(func() { endCall(s.span, err) })
+}
+
+func (s tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.end(err)
+	}
+	return err
+}
+
+// StreamClientInterceptor traces every streaming call as a client span.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if !traced(method) {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	ctx, span := startCall(ctx, method, trace.SpanKindClient)
+	cs, err := streamer(clientContext(ctx), desc, cc, method, opts...)
+	if err != nil {
+		endCall(span, err)
+		return nil, err
+	}
+	s := tracedClientStream{ClientStream: cs, span: span, once: &sync.Once{}}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.end(ctx.Err())
+		case <-cs.Context().Done(): // finished, RecvMsg reports how
+		}
+	}()
+	return s, nil
+}
@@ -0,0 +1,233 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// collector records the bodies posted to it and answers with code
+func collector(t *testing.T, code int) (*httptest.Server, <-chan map[string]interface{}) {
+	t.Helper()
+	bodies := make(chan map[string]interface{}, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with content type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("body is not JSON: %v", err)
+		}
+		bodies <- body
+		w.WriteHeader(code)
+		io.WriteString(w, "collector says no\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, bodies
+}
+
+func testSpans() []sdktrace.ReadOnlySpan {
+	traceID := trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	span := func(id byte) trace.SpanContext {
+		return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{0, 0, 0, 0, 0, 0, 0, id}, TraceFlags: trace.FlagsSampled})
+	}
+	server := resource.NewSchemaless(attribute.String("service.name", "kvserver"))
+	client := resource.NewSchemaless(attribute.String("service.name", "kvctl"))
+	start := time.Unix(1700000000, 5)
+	return tracetest.SpanStubs{
+		{
+			Name: "kv.KVStore/Set", SpanContext: span(1), SpanKind: trace.SpanKindServer,
+			StartTime: start, EndTime: start.Add(time.Millisecond),
+			Attributes: []attribute.KeyValue{
+				attribute.String("request.id", "req-1"),
+				attribute.Int64("rpc.grpc.status_code", 1<<40),
+				attribute.Bool("cached", true),
+				attribute.Float64Slice("ratios", []float64{0.5}),
+			},
+			Status:                 sdktrace.Status{Code: otelcodes.Error, Description: "quota exceeded"},
+			Resource:               server,
+			InstrumentationLibrary: instrumentation.Library{Name: instrumentationName},
+		},
+		{
+			Name: "wal.append", SpanContext: span(2), Parent: span(1), SpanKind: trace.SpanKindInternal,
+			StartTime: start, EndTime: start.Add(time.Microsecond),
+			Events:                 []sdktrace.Event{{Name: "synced", Time: start}},
+			Status:                 sdktrace.Status{Code: otelcodes.Ok},
+			Resource:               server,
+			InstrumentationLibrary: instrumentation.Library{Name: instrumentationName},
+		},
+		{
+			Name: "grpc.health", SpanContext: span(3), SpanKind: trace.SpanKindInternal,
+			StartTime: start, EndTime: start,
+			Resource:               server,
+			InstrumentationLibrary: instrumentation.Library{Name: "other", Version: "1.0"},
+		},
+		{
+			Name: "kv.KVStore/Set", SpanContext: span(4), SpanKind: trace.SpanKindClient,
+			StartTime: start, EndTime: start,
+			Resource:               client,
+			InstrumentationLibrary: instrumentation.Library{Name: instrumentationName},
+		},
+	}.Snapshots()
+}
+
+// get walks the decoded JSON along the path of object keys and array indexes
+func get(t *testing.T, v interface{}, path ...interface{}) interface{} {
+	t.Helper()
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				t.Fatalf("%v is not an object holding %q", v, p)
+			}
+			v = m[p]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || p >= len(a) {
+				t.Fatalf("%v is not an array of more than %d items", v, p)
+			}
+			v = a[p]
+		}
+	}
+	return v
+}
+
+// attrs returns the attributes of an OTLP object as key to value object
+func attrs(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	m := make(map[string]interface{})
+	list, _ := get(t, v, "attributes").([]interface{})
+	for _, kv := range list {
+		m[get(t, kv, "key").(string)] = get(t, kv, "value")
+	}
+	return m
+}
+
+func TestOTLPExport(t *testing.T) {
+	srv, bodies := collector(t, http.StatusOK)
+	if err := NewOTLPExporter(srv.URL).ExportSpans(context.Background(), testSpans()); err != nil {
+		t.Fatal(err)
+	}
+	body := <-bodies
+
+	// grouped by resource, then by scope, in the order first seen
+	resources := get(t, body, "resourceSpans").([]interface{})
+	if len(resources) != 2 {
+		t.Fatalf("got %d resources, want 2", len(resources))
+	}
+	if name := get(t, attrs(t, get(t, resources[0], "resource")), "service.name", "stringValue"); name != "kvserver" {
+		t.Errorf("first resource is %v, want kvserver", name)
+	}
+	scopes := get(t, resources[0], "scopeSpans").([]interface{})
+	if len(scopes) != 2 {
+		t.Fatalf("got %d scopes for kvserver, want 2", len(scopes))
+	}
+	if name := get(t, scopes[0], "scope", "name"); name != instrumentationName {
+		t.Errorf("first scope is %v", name)
+	}
+	if version := get(t, scopes[1], "scope", "version"); version != "1.0" {
+		t.Errorf("second scope version is %v, want 1.0", version)
+	}
+	if n := len(get(t, scopes[0], "spans").([]interface{})); n != 2 {
+		t.Errorf("got %d spans in the first scope, want 2", n)
+	}
+	if kind := get(t, resources[1], "scopeSpans", 0, "spans", 0, "kind"); kind != 3.0 {
+		t.Errorf("client span kind %v, want 3", kind)
+	}
+
+	set := get(t, scopes[0], "spans", 0)
+	for field, want := range map[string]interface{}{
+		"traceId":           "0102030405060708090a0b0c0d0e0f10",
+		"spanId":            "0000000000000001",
+		"name":              "kv.KVStore/Set",
+		"kind":              2.0,
+		"startTimeUnixNano": "1700000000000000005",
+		"endTimeUnixNano":   "1700000000001000005",
+	} {
+		if got := get(t, set, field); got != want {
+			t.Errorf("server span %s is %#v, want %#v", field, got, want)
+		}
+	}
+	if _, ok := set.(map[string]interface{})["parentSpanId"]; ok {
+		t.Error("root span has a parentSpanId")
+	}
+	if code, msg := get(t, set, "status", "code"), get(t, set, "status", "message"); code != 2.0 || msg != "quota exceeded" {
+		t.Errorf("error status is %v %v, want 2 and the description", code, msg)
+	}
+	a := attrs(t, set)
+	if v := get(t, a, "request.id", "stringValue"); v != "req-1" {
+		t.Errorf("request.id is %v", v)
+	}
+	if v := get(t, a, "rpc.grpc.status_code", "intValue"); v != "1099511627776" {
+		t.Errorf("int64 attribute is %#v, want a decimal string", v)
+	}
+	if v := get(t, a, "cached", "boolValue"); v != true {
+		t.Errorf("bool attribute is %#v", v)
+	}
+	if v := get(t, a, "ratios", "arrayValue", "values", 0, "doubleValue"); v != 0.5 {
+		t.Errorf("float slice attribute is %#v", v)
+	}
+
+	appendSpan := get(t, scopes[0], "spans", 1)
+	if parent := get(t, appendSpan, "parentSpanId"); parent != "0000000000000001" {
+		t.Errorf("child parentSpanId is %v", parent)
+	}
+	if kind := get(t, appendSpan, "kind"); kind != 1.0 {
+		t.Errorf("internal span kind %v, want 1", kind)
+	}
+	if code := get(t, appendSpan, "status", "code"); code != 1.0 {
+		t.Errorf("ok status code %v, want 1", code)
+	}
+	if name := get(t, appendSpan, "events", 0, "name"); name != "synced" {
+		t.Errorf("event is %v", name)
+	}
+	if code := get(t, scopes[1], "spans", 0, "status", "code"); code != nil {
+		t.Errorf("unset status code %v, want it left out", code)
+	}
+}
+
+func TestOTLPExportFailure(t *testing.T) {
+	srv, _ := collector(t, http.StatusServiceUnavailable)
+	err := NewOTLPExporter(srv.URL).ExportSpans(context.Background(), testSpans())
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "collector says no") {
+		t.Fatalf("export to a failing collector returned %v, want the status and message", err)
+	}
+}
+
+func TestOTLPExportNothing(t *testing.T) {
+	if err := NewOTLPExporter("http://127.0.0.1:0/unreachable").ExportSpans(context.Background(), nil); err != nil {
+		t.Errorf("exporting no spans returned %v", err)
+	}
+}
+
+func TestOTLPExportFromProvider(t *testing.T) {
+	srv, bodies := collector(t, http.StatusOK)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(NewOTLPExporter(srv.URL)))
+	defer tp.Shutdown(context.Background())
+	ctx, parent := tp.Tracer(instrumentationName).Start(context.Background(), "call")
+	_, child := tp.Tracer(instrumentationName).Start(ctx, "wal.fsync")
+	child.End()
+	body := <-bodies
+	span := get(t, body, "resourceSpans", 0, "scopeSpans", 0, "spans", 0)
+	if get(t, span, "name") != "wal.fsync" || get(t, span, "parentSpanId") != parent.SpanContext().SpanID().String() ||
+		get(t, span, "traceId") != parent.SpanContext().TraceID().String() {
+		t.Errorf("exported span %v, want wal.fsync under %v", span, parent.SpanContext())
+	}
+	parent.End()
+	<-bodies
+}
@@ -0,0 +1,91 @@
+// Package tracing sets up OpenTelemetry tracing for kvservers and their
+// clients, and provides the gRPC interceptors that start a span per call and
+// carry the trace context from clients to servers in the call metadata.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultEndpoint is where the otlp exporter sends spans, the traces path of
+// a collector listening for OTLP/HTTP on its default port.
+const DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+const instrumentationName = "github.com/ss87021456/gRPC-KVStore"
+
+// Config picks where the spans of a process go.
+type Config struct {
+	// Service names the process in the spans, e.g. kvserver.
+	Service string
+	// Exporter is stdout, otlp, or empty to turn tracing off.
+	Exporter string
+	// Endpoint is the OTLP/HTTP traces URL of the otlp exporter,
+	// DefaultEndpoint if empty.
+	Endpoint string
+	// SampleRatio is the share of the traces started here that are recorded,
+	// from 0 to 1. Calls carrying a trace follow the choice of their caller.
+	SampleRatio float64
+}
+
+// Setup installs the exporter picked by cfg as the global tracer provider.
+// The returned func flushes the spans not exported yet and stops the
+// provider, it does nothing when tracing is off.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		var err error
+		if exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout)); err != nil {
+			return nil, err
+		}
+	case "otlp":
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = DefaultEndpoint
+		}
+		exporter = NewOTLPExporter(endpoint)
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q, want stdout or otlp", cfg.Exporter)
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing: sample ratio %g is not between 0 and 1", cfg.SampleRatio)
+	}
+	return Install(cfg.Service, exporter, cfg.SampleRatio).Shutdown, nil
+}
+
+// Install makes a tracer provider batching spans to exporter the global one,
+// e.g. with the in-memory exporter of go.opentelemetry.io/otel/sdk/trace/tracetest.
+func Install(service string, exporter sdktrace.SpanExporter, sampleRatio float64) *sdktrace.TracerProvider {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return tp
+}
+
+// Start starts a span of the key-value store as a child of the span of ctx.
+// Without a provider installed it is a no-op span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Detach returns a context holding the span of ctx but none of its deadline
+// or cancellation, for work that outlives the call that started it.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
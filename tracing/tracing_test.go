@@ -0,0 +1,137 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// install records the spans of the test in memory, the returned func flushes
+// and returns them
+func install(t *testing.T) func() tracetest.SpanStubs {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := Install("test", exporter, 1)
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	return func() tracetest.SpanStubs {
+		if err := tp.ForceFlush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return exporter.GetSpans()
+	}
+}
+
+func attr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// loopback hands a call from the client interceptor to the server one, the
+// outgoing metadata becoming the incoming one as on the wire
+func loopback(handler grpc.UnaryHandler) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		ctx = metadata.NewIncomingContext(context.Background(), md)
+		_, err := UnaryServerInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+}
+
+func TestUnaryInterceptorsPropagate(t *testing.T) {
+	spans := install(t)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, span := Start(ctx, "cache.update")
+		span.End()
+		return nil, status.Error(codes.NotFound, "key: k not exist")
+	}
+	err := UnaryClientInterceptor(context.Background(), "/kv.KVStore/Get", nil, nil, nil, loopback(handler))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("call returned %v, want NotFound", err)
+	}
+
+	got := spans()
+	if len(got) != 3 {
+		t.Fatalf("got %d spans, want the client, server and cache.update spans", len(got))
+	}
+	inner, server, client := got[0], got[1], got[2]
+	if inner.Name != "cache.update" || server.Name != "kv.KVStore/Get" || client.Name != "kv.KVStore/Get" {
+		t.Fatalf("span names %q %q %q", inner.Name, server.Name, client.Name)
+	}
+	if client.SpanKind != trace.SpanKindClient || server.SpanKind != trace.SpanKindServer {
+		t.Errorf("span kinds: client %v, server %v", client.SpanKind, server.SpanKind)
+	}
+	if server.Parent.SpanID() != client.SpanContext.SpanID() || server.SpanContext.TraceID() != client.SpanContext.TraceID() {
+		t.Error("server span does not continue the trace of the client span")
+	}
+	if inner.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("span started by the handler is not a child of the server span")
+	}
+	for _, span := range []tracetest.SpanStub{client, server} {
+		if v, _ := attr(span, "rpc.service"); v.AsString() != "kv.KVStore" {
+			t.Errorf("%v span rpc.service %q", span.SpanKind, v.AsString())
+		}
+		if v, _ := attr(span, "rpc.method"); v.AsString() != "Get" {
+			t.Errorf("%v span rpc.method %q", span.SpanKind, v.AsString())
+		}
+		if v, _ := attr(span, "rpc.grpc.status_code"); v.AsInt64() != int64(codes.NotFound) {
+			t.Errorf("%v span status code %d", span.SpanKind, v.AsInt64())
+		}
+		if span.Status.Code != otelcodes.Error {
+			t.Errorf("%v span status %v, want error", span.SpanKind, span.Status.Code)
+		}
+	}
+}
+
+func TestHealthChecksNotTraced(t *testing.T) {
+	spans := install(t)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	if err := UnaryClientInterceptor(context.Background(), "/grpc.health.v1.Health/Check", nil, nil, nil, loopback(handler)); err != nil {
+		t.Fatal(err)
+	}
+	if got := spans(); len(got) != 0 {
+		t.Errorf("health check traced as %d spans", len(got))
+	}
+}
+
+func TestDetach(t *testing.T) {
+	spans := install(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, parent := Start(ctx, "call")
+	detached := Detach(ctx)
+	cancel()
+	if detached.Err() != nil {
+		t.Fatal("detached context cancelled with the call")
+	}
+	_, child := Start(detached, "background")
+	child.End()
+	parent.End()
+	got := spans()
+	if len(got) != 2 || got[0].Parent.SpanID() != got[1].SpanContext.SpanID() {
+		t.Error("span started after Detach is not a child of the call span")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(Config{})
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Errorf("tracing off: %v", err)
+	}
+	if _, err := Setup(Config{Exporter: "jaeger"}); err == nil {
+		t.Error("unknown exporter accepted")
+	}
+	if _, err := Setup(Config{Exporter: "otlp", SampleRatio: 2}); err == nil {
+		t.Error("sample ratio above 1 accepted")
+	}
+}